@@ -15,14 +15,211 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/artifacts/{id}": {
+            "get": {
+                "description": "Returns an output stored by a render that answered with links instead of inline content. Artifact ids are unguessable and need no Authorization header, so links can be used directly in \u003cimg\u003e or \u003ca\u003e elements. Artifacts expire one hour after they are created. HTML, SVG and XML artifacts are served in a CSP sandbox, so scripts in them do not run.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "artifacts"
+                ],
+                "summary": "Download a rendered artifact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Artifact id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Artifact content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/latexml.css": {
+            "get": {
+                "description": "Returns the LaTeXML stylesheet with the overrides of a theme, rewritten for a css_scope when asked. The URLs written by css=link carry a version parameter that changes with the stylesheet, so those responses are cacheable for a year; requests with a missing or outdated version are cacheable for an hour. Needs no Authorization header.",
+                "produces": [
+                    "text/css"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Download the LaTeXML stylesheet",
+                "parameters": [
+                    {
+                        "enum": [
+                            "default",
+                            "dark",
+                            "journal"
+                        ],
+                        "type": "string",
+                        "default": "default",
+                        "description": "Theme name",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "class",
+                            "shadow"
+                        ],
+                        "type": "string",
+                        "default": "none",
+                        "description": "css_scope the stylesheet is rewritten for",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stylesheet version",
+                        "name": "v",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stylesheet",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/extract": {
+            "post": {
+                "description": "Converts a full LaTeX document to LaTeXML's XML and returns its plain text, for search indexing, together with an outline of its sections, figure and table captions, labels and display equations. Math is written as its TeX source, or with math_text=alt as the linear alt text LaTeXML derives from the formula. Markdown input is converted first, as for the render endpoints. Alias of /v1/extract.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "extract"
+                ],
+                "summary": "Extract text and outline from LaTeX",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}; math_text applies here",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Extraction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/render": {
             "post": {
-                "description": "Converts a full LaTeX document into an HTML fragment with embedded LaTeXML CSS and Presentation MathML.",
+                "description": "Converts a full LaTeX document into an HTML fragment with embedded LaTeXML CSS and math as MathML or images (math_format). The stylesheet is inlined whole or with only the rules used, linked from /assets/latexml.css or left out (css), and themed (theme). Generated images are embedded as data URIs, stored as artifacts, or zipped with the page (html_images). split breaks long documents into one page per section, returned with a manifest as a zip or as artifacts (bundle). sanitize filters the output through an allow-list for embedding untrusted LaTeX. With html_mode=document the answer is a complete HTML5 page with lang, title and meta tags taken from the source and the metadata object. Alias of /v1/render/html.",
                 "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
                     "multipart/form-data"
                 ],
                 "produces": [
-                    "text/html"
+                    "text/html",
+                    "application/zip",
+                    "application/json"
                 ],
                 "tags": [
                     "render"
@@ -38,7 +235,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "LaTeX source code",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
                         "name": "content",
                         "in": "formData",
                         "required": true
@@ -48,11 +245,17 @@ const docTemplate = `{
                         "description": "JSON map of images. Example: {\\",
                         "name": "images",
                         "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "HTML with embedded CSS",
+                        "description": "HTML with embedded CSS, or a zip of index.html and its images with html_images=zip",
                         "schema": {
                             "type": "string"
                         }
@@ -69,28 +272,116 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/render/pdf": {
+        "/render/batch": {
+            "post": {
+                "description": "Renders every item in parallel, at most four at a time across all batch and merge requests, and returns one result per item, in request order. A failing item is reported in its own result and does not fail the batch. Text outputs are returned as-is, binary outputs (PDF, PNG) base64-encoded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render a batch of formulas or documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Items to render",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.BatchReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/docx": {
             "post": {
-                "description": "Compiles a full LaTeX document into a PDF using pdflatex.",
+                "description": "Converts a LaTeX document to a Word or OpenDocument file with pandoc. Equations become native OMML in DOCX and MathML formulas in ODT; figures, tables, footnotes, cross-references and the title block are kept, and citations are formatted from a .bib file passed in images. Constructs pandoc could not convert are skipped; with response=report the answer is an OfficeReport JSON holding the document and a warning for each of them. Needs pandoc on the server. Aliases of /v1/render/docx and /v1/render/odt.",
                 "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
                     "multipart/form-data"
                 ],
                 "produces": [
-                    "application/pdf"
+                    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+                    "application/vnd.oasis.opendocument.text",
+                    "application/json"
                 ],
                 "tags": [
                     "render"
                 ],
-                "summary": "Render LaTeX to PDF",
+                "summary": "Render LaTeX to DOCX or ODT",
                 "parameters": [
                     {
                         "type": "string",
@@ -101,7 +392,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "LaTeX source code",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
                         "name": "content",
                         "in": "formData",
                         "required": true
@@ -111,11 +402,17 @@ const docTemplate = `{
                         "description": "JSON map of images. Example: {\\",
                         "name": "images",
                         "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "PDF document",
+                        "description": "DOCX or ODT document, or OfficeReport JSON with response=report",
                         "schema": {
                             "type": "file"
                         }
@@ -132,27 +429,2501 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "string",
-                    "example": "Undefined control sequence"
-                },
-                "error": {
-                    "type": "string",
-                    "example": "latex render failed"
+        },
+        "/render/epub": {
+            "post": {
+                "description": "Converts a full LaTeX document into an EPUB3 archive with LaTeXML: one XHTML page per section, or per the unit given in split, with math as MathML or images (math_format), the document's images, the LaTeXML stylesheet and a generated navigation document. The package metadata takes the title and authors from \\title and \\author. Alias of /v1/render/epub.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/epub+zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to EPUB3",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "EPUB3 archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/jats": {
+            "post": {
+                "description": "Converts a full LaTeX document to LaTeXML's XML with presentation MathML and transforms it with a bundled XSLT stylesheet into a JATS 1.3 Archiving article or a TEI P5 document. The result is validated against the JATS DTD or the TEI RelaxNG schema vendored with the service; invalid output fails with 422 listing the validity errors, and fails with 500 if the schema is missing, or with response=report is returned as an XMLReport JSON saying whether and how it validated. Graphics keep the file names used in the source. Aliases of /v1/render/jats and /v1/render/tei.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/jats+xml",
+                    "application/tei+xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to JATS or TEI XML",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "JATS or TEI document, or XMLReport JSON with response=report",
+                        "schema": {
+                            "$ref": "#/definitions/handler.XMLReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/jpeg": {
+            "post": {
+                "description": "Compiles a LaTeX document to PDF with the selected engine and rasterises the selected pages at the requested dpi. Pages whose pixel size would exceed max_dimension are rejected before rendering. transparent keeps the background transparent in PNG and WebP. A single page is returned as the image; several pages as a zip of page-N files, or with bundle=links as a JSON list of artifact links. Aliases of /v1/render/png, /v1/render/jpeg and /v1/render/webp.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "image/png",
+                    "image/jpeg",
+                    "image/webp",
+                    "application/zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to PNG, JPEG or WebP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page image, zip of page images, or PageLinks JSON",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/math": {
+            "post": {
+                "description": "Renders one formula to MathML, SVG or PNG, without wrapping it in a document. MathML is produced by a pool of persistent LaTeXML processes so per-snippet latency stays low. A text/plain body is the expression itself, with the other fields as query parameters.",
+                "consumes": [
+                    "application/json",
+                    "text/plain",
+                    "application/x-tex"
+                ],
+                "produces": [
+                    "application/mathml+xml",
+                    "image/svg+xml",
+                    "image/png"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render a TeX math expression",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Expression and output settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.MathReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "MathML, SVG or PNG",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/merge": {
+            "post": {
+                "description": "Compiles every document in parallel, sharing the batch limit of four renders at a time, each with its own engine and options, and merges the PDFs in request order with qpdf, pages unchanged, adding one bookmark per document. The result keeps the first document's metadata and PDF/A identification, so metadata is only accepted on the first document and pdfa must match across documents. page_numbers stamps continuous numbering over the result. A failing document fails the request with 422 listing every failure, unless skip_failed is set; with response=report the answer is a MergeReport giving each document's status and page range.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/pdf",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Merge LaTeX documents into one PDF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Documents to merge",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.MergeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merged PDF, or MergeReport JSON with response=report",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/odt": {
+            "post": {
+                "description": "Converts a LaTeX document to a Word or OpenDocument file with pandoc. Equations become native OMML in DOCX and MathML formulas in ODT; figures, tables, footnotes, cross-references and the title block are kept, and citations are formatted from a .bib file passed in images. Constructs pandoc could not convert are skipped; with response=report the answer is an OfficeReport JSON holding the document and a warning for each of them. Needs pandoc on the server. Aliases of /v1/render/docx and /v1/render/odt.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+                    "application/vnd.oasis.opendocument.text",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to DOCX or ODT",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "DOCX or ODT document, or OfficeReport JSON with response=report",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/pdf": {
+            "post": {
+                "description": "Compiles a full LaTeX document into a PDF using pdflatex, or the engine selected in options. A metadata object sets the Info dictionary and XMP fields. A post_process object runs watermark, page stamp, encryption and linearisation steps over the compiled PDF. With response=report the answer is a PDFReport JSON holding the PDF, its metadata, page count and page sizes as read back from the file and, with thumbnail=true, a first-page preview from the same compilation. With pdfa=1b or 2b the document is made PDF/A and validated, with veraPDF when installed; a non-compliant result fails with 422 listing the failures, or is returned with them under pdfa in a report. Alias of /v1/render/pdf.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/pdf",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to PDF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded PDFMetadata: title, author, subject, keywords and custom XMP fields",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded PostProcessing: watermark, stamp, encrypt and linearize steps",
+                        "name": "post_process",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PDF document, or PDFReport JSON with response=report",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/png": {
+            "post": {
+                "description": "Compiles a LaTeX document to PDF with the selected engine and rasterises the selected pages at the requested dpi. Pages whose pixel size would exceed max_dimension are rejected before rendering. transparent keeps the background transparent in PNG and WebP. A single page is returned as the image; several pages as a zip of page-N files, or with bundle=links as a JSON list of artifact links. Aliases of /v1/render/png, /v1/render/jpeg and /v1/render/webp.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "image/png",
+                    "image/jpeg",
+                    "image/webp",
+                    "application/zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to PNG, JPEG or WebP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page image, zip of page images, or PageLinks JSON",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/svg": {
+            "post": {
+                "description": "Compiles a LaTeX document with the selected engine and converts each page to SVG with dvisvgm, through the engine's DVI output or its PDF (svg_route). Standalone-class documents are cropped to their content. At most 100 pages are converted; select them with pages. A single page is returned as image/svg+xml, several pages as a zip of page-N.svg files, or with bundle=links as a JSON list of artifact links. Alias of /v1/render/svg.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "image/svg+xml",
+                    "application/zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to SVG",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SVG image, zip of SVG pages, or PageLinks JSON",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/tei": {
+            "post": {
+                "description": "Converts a full LaTeX document to LaTeXML's XML with presentation MathML and transforms it with a bundled XSLT stylesheet into a JATS 1.3 Archiving article or a TEI P5 document. The result is validated against the JATS DTD or the TEI RelaxNG schema vendored with the service; invalid output fails with 422 listing the validity errors, and fails with 500 if the schema is missing, or with response=report is returned as an XMLReport JSON saying whether and how it validated. Graphics keep the file names used in the source. Aliases of /v1/render/jats and /v1/render/tei.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/jats+xml",
+                    "application/tei+xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to JATS or TEI XML",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "JATS or TEI document, or XMLReport JSON with response=report",
+                        "schema": {
+                            "$ref": "#/definitions/handler.XMLReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/thumbnail": {
+            "post": {
+                "description": "Compiles a LaTeX document to PDF and returns only a PNG preview of its first page, thumbnail_size pixels on its longer side. To get the PDF and its thumbnail from one compilation, use /render/pdf with response=report and thumbnail=true. Alias of /v1/render/thumbnail.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render a first-page thumbnail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PNG thumbnail",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/render/webp": {
+            "post": {
+                "description": "Compiles a LaTeX document to PDF with the selected engine and rasterises the selected pages at the requested dpi. Pages whose pixel size would exceed max_dimension are rejected before rendering. transparent keeps the background transparent in PNG and WebP. A single page is returned as the image; several pages as a zip of page-N files, or with bundle=links as a JSON list of artifact links. Aliases of /v1/render/png, /v1/render/jpeg and /v1/render/webp.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "image/png",
+                    "image/jpeg",
+                    "image/webp",
+                    "application/zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "render"
+                ],
+                "summary": "Render LaTeX to PNG, JPEG or WebP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}",
+                        "name": "content",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON map of images. Example: {\\",
+                        "name": "images",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded RenderOptions, as documented on /v1/render/{format}",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page image, zip of page images, or PageLinks JSON",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{name}/render": {
+            "post": {
+                "description": "Fills the template name with data, escaping every inserted value for TeX (\u0026 % $ # _ { } ~ ^ \\), and renders the resulting document to format, pdf by default, with the same options, metadata and post-processing as /v1/render/{format}. Options may also be passed as query parameters. Alias of /v1/templates/{name}/render.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/pdf",
+                    "text/html",
+                    "image/svg+xml",
+                    "image/png",
+                    "image/jpeg",
+                    "image/webp",
+                    "application/zip",
+                    "application/epub+zip",
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Render a document template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Data, output format and options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TemplateRenderReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rendered document",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/templates/{name}": {
+            "put": {
+                "description": "Stores the body as the template name, replacing any template of that name. Templates are LaTeX documents with text/template actions between \u003c\u003c and \u003e\u003e: \u003c\u003c.field\u003e\u003e inserts a value escaped for TeX, \u003c\u003crange .items\u003e\u003e…\u003c\u003cend\u003e\u003e loops, \u003c\u003cif .paid\u003e\u003e…\u003c\u003celse\u003e\u003e…\u003c\u003cend\u003e\u003e branches, and \u003c\u003craw .field\u003e\u003e inserts trusted TeX unescaped. The template is parsed before it is stored. Needs the admin key.",
+                "consumes": [
+                    "text/plain",
+                    "application/x-tex"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Upload a document template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer admin API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Template name: lowercase letters, digits, - and _",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template source",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Template replaced",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TemplateInfo"
+                        }
+                    },
+                    "201": {
+                        "description": "Template created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TemplateInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the template name from the registry and from TEMPLATES_DIR. Needs the admin key.",
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Delete a document template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer admin API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/render/{format}": {
+            "post": {
+                "description": "Versioned render endpoint. Accepts the same bodies as /render (raw TeX, JSON or multipart); the JSON form is documented here. Options may also be passed as query parameters.",
+                "consumes": [
+                    "application/json",
+                    "text/plain",
+                    "application/x-tex",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "text/html",
+                    "application/pdf",
+                    "image/svg+xml",
+                    "image/png",
+                    "image/jpeg",
+                    "image/webp",
+                    "application/zip",
+                    "application/epub+zip",
+                    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+                    "application/vnd.oasis.opendocument.text",
+                    "application/jats+xml",
+                    "application/tei+xml",
+                    "application/json"
+                ],
+                "tags": [
+                    "v1"
+                ],
+                "summary": "Render LaTeX to the requested format",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html",
+                            "pdf",
+                            "svg",
+                            "png",
+                            "jpeg",
+                            "webp",
+                            "thumbnail",
+                            "epub",
+                            "docx",
+                            "odt",
+                            "jats",
+                            "tei"
+                        ],
+                        "type": "string",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Document, images and options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RenderReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rendered document",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/templates": {
+            "get": {
+                "description": "Returns the names of the templates in the registry: those loaded from TEMPLATES_DIR at startup and those uploaded through the admin API.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "List document templates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer API key",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TemplateList"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.BatchItem": {
+            "type": "object",
+            "properties": {
+                "document": {
+                    "description": "Document is the source, images and options of a document item.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.RenderReq"
+                        }
+                    ]
+                },
+                "format": {
+                    "description": "Format is the output of a document item.",
+                    "type": "string",
+                    "enum": [
+                        "html",
+                        "pdf",
+                        "svg",
+                        "png",
+                        "jpeg",
+                        "webp",
+                        "thumbnail",
+                        "epub",
+                        "docx",
+                        "odt",
+                        "jats",
+                        "tei"
+                    ],
+                    "example": "html"
+                },
+                "math": {
+                    "description": "Math is the expression of a math item.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.MathReq"
+                        }
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "math",
+                        "document"
+                    ],
+                    "example": "math"
+                }
+            }
+        },
+        "handler.BatchReq": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BatchItem"
+                    }
+                }
+            }
+        },
+        "handler.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BatchResult"
+                    }
+                }
+            }
+        },
+        "handler.BatchResult": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body is the output, base64-encoded when Encoding is base64.",
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/mathml+xml; charset=utf-8"
+                },
+                "encoding": {
+                    "type": "string",
+                    "enum": [
+                        "utf8",
+                        "base64"
+                    ],
+                    "example": "utf8"
+                },
+                "error": {
+                    "$ref": "#/definitions/handler.ErrorResponse"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "handler.Encryption": {
+            "type": "object",
+            "properties": {
+                "allow": {
+                    "description": "Allow lists what readers may do without the owner password.",
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "print",
+                            "copy",
+                            "modify",
+                            "annotate"
+                        ]
+                    },
+                    "example": [
+                        "print"
+                    ]
+                },
+                "owner_password": {
+                    "type": "string"
+                },
+                "user_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "Undefined control sequence"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "latex render failed"
+                }
+            }
+        },
+        "handler.Extraction": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Ada Lovelace"
+                    ]
+                },
+                "outline": {
+                    "$ref": "#/definitions/handler.Outline"
+                },
+                "text": {
+                    "description": "Text is the plain text of the whole document, with blank lines\nbetween paragraphs and math written as math_text says.",
+                    "type": "string",
+                    "example": "Heat flow\n\n1 Introduction\n\nThe heat equation u_t = \\Delta u ..."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Heat flow"
+                }
+            }
+        },
+        "handler.ImageInput": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.MathReq": {
+            "type": "object",
+            "properties": {
+                "dpi": {
+                    "description": "DPI is the resolution of PNG output.",
+                    "type": "integer",
+                    "maximum": 600,
+                    "minimum": 72,
+                    "example": 150
+                },
+                "format": {
+                    "description": "Format is the output: MathML markup, an SVG image or a PNG image.",
+                    "type": "string",
+                    "enum": [
+                        "mathml",
+                        "svg",
+                        "png"
+                    ],
+                    "example": "mathml"
+                },
+                "macros": {
+                    "description": "Macros are definitions such as \\newcommand made available to the expression.",
+                    "type": "string",
+                    "example": "\\newcommand{\\R}{\\mathbb{R}}"
+                },
+                "mode": {
+                    "description": "Mode renders the expression as inline or display math.",
+                    "type": "string",
+                    "enum": [
+                        "inline",
+                        "display"
+                    ],
+                    "example": "inline"
+                },
+                "tex": {
+                    "description": "TeX is the expression, without surrounding $ or \\[ \\].",
+                    "type": "string",
+                    "example": "e^{i\\pi} + 1 = 0"
+                }
+            }
+        },
+        "handler.MergeDocument": {
+            "type": "object",
+            "properties": {
+                "archive": {
+                    "description": "Archive is a base64-encoded zip holding the source and its files, used\ninstead of content.",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "\\documentclass{article}\\begin{document}Hello\\end{document}"
+                },
+                "images": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handler.ImageInput"
+                    }
+                },
+                "main": {
+                    "description": "Main is the archive entry to compile.",
+                    "type": "string",
+                    "example": "main.tex"
+                },
+                "metadata": {
+                    "description": "Metadata is written into PDF output.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.PDFMetadata"
+                        }
+                    ]
+                },
+                "options": {
+                    "$ref": "#/definitions/handler.RenderOptions"
+                },
+                "post_process": {
+                    "description": "PostProcess is applied to PDF output after compilation.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.PostProcessing"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "Title labels the document's bookmark. It defaults to the metadata\ntitle, then to the \\title of the source.",
+                    "type": "string",
+                    "example": "On Computable Numbers"
+                }
+            }
+        },
+        "handler.MergeReq": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.MergeDocument"
+                    }
+                },
+                "page_numbers": {
+                    "description": "PageNumbers stamps continuous page numbers over the merged PDF.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.Stamp"
+                        }
+                    ]
+                },
+                "response": {
+                    "description": "Response returns the merged PDF, or a MergeReport.",
+                    "type": "string",
+                    "enum": [
+                        "pdf",
+                        "report"
+                    ],
+                    "example": "pdf"
+                },
+                "skip_failed": {
+                    "description": "SkipFailed merges the documents that compiled when others failed,\ninstead of failing the request.",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.Outline": {
+            "type": "object",
+            "properties": {
+                "equations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OutlineEquation"
+                    }
+                },
+                "figures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OutlineFloat"
+                    }
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OutlineSection"
+                    }
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OutlineFloat"
+                    }
+                }
+            }
+        },
+        "handler.OutlineEquation": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "eq:heat"
+                    ]
+                },
+                "number": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "tex": {
+                    "type": "string",
+                    "example": "u_t = \\Delta u"
+                },
+                "text": {
+                    "description": "Text is LaTeXML's linear alt text of the formula, if it parsed.",
+                    "type": "string",
+                    "example": "u_t = Delta u"
+                }
+            }
+        },
+        "handler.OutlineFloat": {
+            "type": "object",
+            "properties": {
+                "caption": {
+                    "type": "string",
+                    "example": "Temperature over time."
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "fig:temp"
+                    ]
+                },
+                "number": {
+                    "type": "string",
+                    "example": "3"
+                }
+            }
+        },
+        "handler.OutlineSection": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "sec:results"
+                    ]
+                },
+                "level": {
+                    "type": "string",
+                    "example": "section"
+                },
+                "number": {
+                    "type": "string",
+                    "example": "2.1"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Results"
+                }
+            }
+        },
+        "handler.PDFMetadata": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "Alan Turing"
+                },
+                "custom": {
+                    "description": "Custom holds additional XMP fields, in the pdfx namespace. Names must\nstart with a letter and contain only letters, digits and underscores.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "turing machine",
+                        "decision problem"
+                    ]
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "Computability"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "On Computable Numbers"
+                }
+            }
+        },
+        "handler.PostProcessing": {
+            "type": "object",
+            "properties": {
+                "encrypt": {
+                    "$ref": "#/definitions/handler.Encryption"
+                },
+                "linearize": {
+                    "description": "Linearize optimises the PDF for fast web view.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "stamp": {
+                    "$ref": "#/definitions/handler.Stamp"
+                },
+                "watermark": {
+                    "$ref": "#/definitions/handler.Watermark"
+                }
+            }
+        },
+        "handler.RenderOptions": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "Ada Lovelace"
+                },
+                "bundle": {
+                    "description": "Bundle returns several pages as a zip, or as JSON links to artifacts.",
+                    "type": "string",
+                    "enum": [
+                        "zip",
+                        "links"
+                    ],
+                    "example": "zip"
+                },
+                "crop": {
+                    "description": "Crop trims image output to its content or keeps the page size. auto\ncrops standalone-class documents only.",
+                    "type": "string",
+                    "enum": [
+                        "auto",
+                        "content",
+                        "page"
+                    ],
+                    "example": "auto"
+                },
+                "css": {
+                    "description": "CSS delivers the LaTeXML stylesheet of HTML output: inlined whole,\ninlined with only the rules the output uses, linked from\n/assets/latexml.css, or left out.",
+                    "type": "string",
+                    "enum": [
+                        "inline",
+                        "used",
+                        "link",
+                        "none"
+                    ],
+                    "example": "inline"
+                },
+                "css_scope": {
+                    "description": "CSSScope keeps the stylesheet of an HTML fragment from styling the\npage it is embedded in: class prefixes every rule with a wrapper class\nand wraps the fragment in it, shadow wraps fragment and stylesheet in a\ndeclarative shadow root.",
+                    "type": "string",
+                    "enum": [
+                        "none",
+                        "class",
+                        "shadow"
+                    ],
+                    "example": "none"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2024-03-01"
+                },
+                "dpi": {
+                    "description": "DPI is the resolution of raster image output.",
+                    "type": "integer",
+                    "maximum": 600,
+                    "minimum": 72,
+                    "example": 150
+                },
+                "engine": {
+                    "description": "Engine is the TeX engine used for PDF output.",
+                    "type": "string",
+                    "enum": [
+                        "pdflatex",
+                        "xelatex",
+                        "lualatex"
+                    ],
+                    "example": "pdflatex"
+                },
+                "html_images": {
+                    "description": "HTMLImages keeps the images of HTML output, such as figures, TikZ\npictures and math images: embedded as data URIs, stored as artifacts\nand linked, or returned with the page in a zip.",
+                    "type": "string",
+                    "enum": [
+                        "inline",
+                        "links",
+                        "zip"
+                    ],
+                    "example": "inline"
+                },
+                "html_mode": {
+                    "description": "HTMLMode returns an embeddable fragment or a whole HTML document.",
+                    "type": "string",
+                    "enum": [
+                        "fragment",
+                        "document"
+                    ],
+                    "example": "fragment"
+                },
+                "input_format": {
+                    "description": "InputFormat is the language of content. Markdown, with $...$ math, is\nconverted to LaTeX through Template before rendering.",
+                    "type": "string",
+                    "enum": [
+                        "latex",
+                        "markdown"
+                    ],
+                    "example": "latex"
+                },
+                "lang": {
+                    "description": "Lang is the BCP 47 language of a whole HTML document, e.g. \"es\".\nEmpty means the babel or polyglossia main language, or \"en\".",
+                    "type": "string",
+                    "example": "es"
+                },
+                "math_format": {
+                    "description": "MathFormat selects how HTML output shows math: Presentation or Content\nMathML, both as parallel markup with the first one primary, either one\nannotated with the TeX source (+tex), or SVG or PNG images.",
+                    "type": "string",
+                    "enum": [
+                        "pmml",
+                        "cmml",
+                        "pmml+cmml",
+                        "cmml+pmml",
+                        "pmml+tex",
+                        "cmml+tex",
+                        "pmml+cmml+tex",
+                        "svg",
+                        "png"
+                    ],
+                    "example": "pmml"
+                },
+                "math_text": {
+                    "description": "MathText writes math in extracted text as its TeX source, or as the\nlinear alt text LaTeXML derives from the parsed formula.",
+                    "type": "string",
+                    "enum": [
+                        "tex",
+                        "alt"
+                    ],
+                    "example": "tex"
+                },
+                "max_dimension": {
+                    "description": "MaxDimension rejects raster output whose width or height, in pixels,\nwould exceed it.",
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 16,
+                    "example": 4096
+                },
+                "pages": {
+                    "description": "Pages selects the pages of image output, e.g. \"1,3-5\". Empty means all.",
+                    "type": "string",
+                    "example": "1-2"
+                },
+                "passes": {
+                    "description": "Passes is how many times the engine runs, to resolve references and TOCs.",
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "pdfa": {
+                    "description": "PDFA produces a PDF/A-1b or PDF/A-2b file and validates it. Empty means\na plain PDF.",
+                    "type": "string",
+                    "enum": [
+                        "1b",
+                        "2b"
+                    ],
+                    "example": "2b"
+                },
+                "reproducible": {
+                    "description": "Reproducible makes PDF output byte-identical across renders of the same\ninput: fixed timestamps and a trailer /ID derived from the source.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "response": {
+                    "description": "Response returns the PDF itself, or a PDFReport JSON wrapping it with\nthe extras requested below. For JATS and TEI, report returns an\nXMLReport with the validation diagnostics, and for DOCX and ODT an\nOfficeReport with the conversion warnings.",
+                    "type": "string",
+                    "enum": [
+                        "pdf",
+                        "report"
+                    ],
+                    "example": "pdf"
+                },
+                "sanitize": {
+                    "description": "Sanitize filters HTML output through an allow-list of HTML, MathML and\nSVG elements and attributes, and drops links with unsafe URL schemes,\nfor embedding output of untrusted LaTeX.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "source_date_epoch": {
+                    "description": "SourceDateEpoch is the date, in Unix seconds, that reproducible renders\nare stamped with and that \\today prints. Reproducible renders need it.",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1700000000
+                },
+                "split": {
+                    "description": "Split breaks HTML output into one page per part, chapter, section or\nsubsection. The pages and a manifest of them are bundled as Bundle\nsays: a zip, or artifacts with the manifest as the response.",
+                    "type": "string",
+                    "enum": [
+                        "part",
+                        "chapter",
+                        "section",
+                        "subsection"
+                    ],
+                    "example": "section"
+                },
+                "strip_ids": {
+                    "description": "StripIDs also drops id attributes when sanitizing.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "svg_fonts": {
+                    "description": "SVGFonts draws glyphs as paths or embeds the fonts in the SVG.",
+                    "type": "string",
+                    "enum": [
+                        "paths",
+                        "embed"
+                    ],
+                    "example": "paths"
+                },
+                "svg_route": {
+                    "description": "SVGRoute converts to SVG from the engine's DVI output or from its PDF.",
+                    "type": "string",
+                    "enum": [
+                        "dvi",
+                        "pdf"
+                    ],
+                    "example": "dvi"
+                },
+                "template": {
+                    "description": "Template is the document class Markdown is converted into.",
+                    "type": "string",
+                    "enum": [
+                        "article",
+                        "report",
+                        "beamer"
+                    ],
+                    "example": "article"
+                },
+                "theme": {
+                    "description": "Theme is the stylesheet theme of HTML output, from the server's theme\nregistry.",
+                    "type": "string",
+                    "enum": [
+                        "default",
+                        "dark",
+                        "journal"
+                    ],
+                    "example": "default"
+                },
+                "thumbnail": {
+                    "description": "Thumbnail adds a first-page PNG preview to a PDF report.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "thumbnail_size": {
+                    "description": "ThumbnailSize is the length, in pixels, of the thumbnail's longer side.",
+                    "type": "integer",
+                    "maximum": 1024,
+                    "minimum": 16,
+                    "example": 256
+                },
+                "timeout": {
+                    "description": "Timeout bounds the whole render, in seconds.",
+                    "type": "integer",
+                    "maximum": 120,
+                    "minimum": 1,
+                    "example": 20
+                },
+                "title": {
+                    "description": "Title, Author and Date fill the template of Markdown input, over any\nYAML metadata block in the content.",
+                    "type": "string",
+                    "example": "Lecture 3"
+                },
+                "transparent": {
+                    "description": "Transparent keeps the page background transparent in PNG and WebP output.",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.RenderReq": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "\\documentclass{article}\\begin{document}Hello\\end{document}"
+                },
+                "images": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handler.ImageInput"
+                    }
+                },
+                "metadata": {
+                    "description": "Metadata is written into PDF output.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.PDFMetadata"
+                        }
+                    ]
+                },
+                "options": {
+                    "$ref": "#/definitions/handler.RenderOptions"
+                },
+                "post_process": {
+                    "description": "PostProcess is applied to PDF output after compilation.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.PostProcessing"
+                        }
+                    ]
+                }
+            }
+        },
+        "handler.Stamp": {
+            "type": "object",
+            "properties": {
+                "font_size": {
+                    "type": "integer",
+                    "maximum": 400,
+                    "minimum": 6,
+                    "example": 9
+                },
+                "position": {
+                    "type": "string",
+                    "enum": [
+                        "bottom-center",
+                        "bottom-left",
+                        "bottom-right",
+                        "top-center",
+                        "top-left",
+                        "top-right"
+                    ],
+                    "example": "bottom-center"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Confidential - page {page} of {pages}"
+                }
+            }
+        },
+        "handler.TemplateInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "invoice"
+                }
+            }
+        },
+        "handler.TemplateList": {
+            "type": "object",
+            "properties": {
+                "templates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "invoice",
+                        "certificate"
+                    ]
+                }
+            }
+        },
+        "handler.TemplateRenderReq": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Data is what the template's actions read, as in \u003c\u003c.client.name\u003e\u003e.",
+                    "type": "object"
+                },
+                "format": {
+                    "description": "Format is the output format, as in /v1/render/{format}.",
+                    "type": "string",
+                    "enum": [
+                        "html",
+                        "pdf",
+                        "svg",
+                        "png",
+                        "jpeg",
+                        "webp",
+                        "thumbnail",
+                        "epub",
+                        "docx",
+                        "odt",
+                        "jats",
+                        "tei"
+                    ],
+                    "example": "pdf"
+                },
+                "images": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handler.ImageInput"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/handler.PDFMetadata"
+                },
+                "options": {
+                    "$ref": "#/definitions/handler.RenderOptions"
+                },
+                "post_process": {
+                    "$ref": "#/definitions/handler.PostProcessing"
+                }
+            }
+        },
+        "handler.Watermark": {
+            "type": "object",
+            "properties": {
+                "angle": {
+                    "description": "Angle is the counter-clockwise rotation, in degrees.",
+                    "type": "number",
+                    "example": 45
+                },
+                "color": {
+                    "type": "string",
+                    "enum": [
+                        "gray",
+                        "red",
+                        "blue",
+                        "black"
+                    ],
+                    "example": "gray"
+                },
+                "font_size": {
+                    "type": "integer",
+                    "maximum": 400,
+                    "minimum": 6,
+                    "example": 72
+                },
+                "image": {
+                    "description": "Image is the name of an entry of the request's images.",
+                    "type": "string",
+                    "example": "logo.png"
+                },
+                "opacity": {
+                    "description": "Opacity is between 0 (invisible) and 1 (opaque).",
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.15
+                },
+                "scale": {
+                    "description": "Scale is the image width as a fraction of the page width.",
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.5
+                },
+                "text": {
+                    "type": "string",
+                    "example": "DRAFT"
+                }
+            }
+        },
+        "handler.XMLReport": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "jats",
+                        "tei"
+                    ],
+                    "example": "jats"
+                },
+                "validation": {
+                    "$ref": "#/definitions/handler.XMLValidation"
+                },
+                "xml": {
+                    "description": "XML is the document.",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.XMLValidation": {
+            "type": "object",
+            "properties": {
+                "diagnostics": {
+                    "description": "Diagnostics lists the validity errors found, by line of the XML.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "line 12: Element sec content does not follow the DTD"
+                    ]
+                },
+                "schema": {
+                    "description": "Schema is the JATS DTD or the TEI RelaxNG schema checked against.",
+                    "type": "string",
+                    "example": "JATS-archivearticle1-3-mathml3.dtd"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "validated": {
+                    "description": "Validated is false when the schema is not installed, in which case\nValid says nothing about the document.",
+                    "type": "boolean"
                 }
             }
         }
@@ -0,0 +1,123 @@
+// Package artifact keeps rendered outputs on local disk for a limited time,
+// so that they can be fetched by URL after the render request has returned.
+//
+// Artifacts live on the instance that produced them: behind a load balancer
+// or on Lambda, a link only resolves while requests reach the same instance.
+package artifact
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned for unknown or expired artifacts.
+var ErrNotFound = errors.New("artifact not found")
+
+// Artifact is a stored output.
+type Artifact struct {
+	// Path is the file holding the artifact's bytes.
+	Path        string    `json:"-"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Expires     time.Time `json:"expires"`
+}
+
+// Store is a directory of artifacts, each kept for ttl after it is put.
+type Store struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewStore returns a store keeping artifacts in dir for ttl.
+func NewStore(dir string, ttl time.Duration) *Store {
+	return &Store{dir: dir, ttl: ttl}
+}
+
+// Put stores data and returns the id to fetch it with. Expired artifacts are
+// swept on every put.
+func (s *Store) Put(name, contentType string, data []byte) (string, error) {
+	id := NewID()
+	return id, s.PutID(id, name, contentType, data)
+}
+
+// NewID returns an id for PutID, for artifacts that must know their URLs
+// before they are stored, such as pages linking to each other.
+func NewID() string {
+	return uuid.NewString()
+}
+
+// PutID stores data under an id returned by NewID.
+func (s *Store) PutID(id, name, contentType string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	s.sweep()
+
+	meta, err := json.Marshal(Artifact{
+		Name:        name,
+		ContentType: contentType,
+		Expires:     time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.dataPath(id), data, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.metaPath(id), meta, 0600); err != nil {
+		os.Remove(s.dataPath(id))
+		return err
+	}
+	return nil
+}
+
+// Get returns the artifact stored under id, or ErrNotFound.
+func (s *Store) Get(id string) (*Artifact, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	meta, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var a Artifact
+	if err := json.Unmarshal(meta, &a); err != nil || time.Now().After(a.Expires) {
+		return nil, ErrNotFound
+	}
+	a.Path = s.dataPath(id)
+	return &a, nil
+}
+
+// sweep removes the artifacts whose ttl has elapsed.
+func (s *Store) sweep() {
+	metas, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-s.ttl)
+	for _, meta := range metas {
+		info, err := os.Stat(meta)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		id := filepath.Base(meta[:len(meta)-len(".json")])
+		os.Remove(s.dataPath(id))
+		os.Remove(meta)
+	}
+}
+
+func (s *Store) dataPath(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *Store) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
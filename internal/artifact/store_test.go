@@ -0,0 +1,50 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_PutGet(t *testing.T) {
+	s := NewStore(t.TempDir(), time.Hour)
+	id, err := s.Put("page-1.png", "image/png", []byte("data"))
+	require.NoError(t, err)
+
+	a, err := s.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, "page-1.png", a.Name)
+	assert.Equal(t, "image/png", a.ContentType)
+	data, err := os.ReadFile(a.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+func TestStore_Expired(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir, 50*time.Millisecond)
+	id, err := s.Put("page-1.png", "image/png", []byte("data"))
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = s.Get(id)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The next put sweeps the expired artifact from disk.
+	_, err = s.Put("page-2.png", "image/png", []byte("data"))
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, id))
+	assert.NoFileExists(t, filepath.Join(dir, id+".json"))
+}
+
+func TestStore_UnknownID(t *testing.T) {
+	s := NewStore(t.TempDir(), time.Hour)
+	for _, id := range []string{NewID(), "not-a-uuid", "../store.go"} {
+		_, err := s.Get(id)
+		assert.ErrorIs(t, err, ErrNotFound, "id %s", id)
+	}
+}
@@ -0,0 +1,120 @@
+// Package doctemplate keeps the LaTeX document templates that requests fill
+// with data and render.
+//
+// Templates are the .tex files of a directory, read when the store is
+// opened; templates put through the store are written there too. On Lambda
+// the image is read-only and every instance has its own /tmp, so uploads
+// only last as long as the instance; templates meant to stay belong in the
+// image.
+package doctemplate
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned for unknown templates.
+var ErrNotFound = errors.New("template not found")
+
+// ext is the extension of template files.
+const ext = ".tex"
+
+// validName matches the names templates can have, which are also their
+// file names.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidName reports whether name can name a template.
+func ValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+// Store is a directory of templates, cached in memory.
+type Store struct {
+	dir string
+
+	mu      sync.RWMutex
+	sources map[string]string
+}
+
+// Open returns a store of the templates in dir. A missing directory is an
+// empty store, created on the first put.
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir, sources: map[string]string{}}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ext)
+		if !ValidName(name) {
+			continue
+		}
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		s.sources[name] = string(source)
+	}
+	return s, nil
+}
+
+// Get returns the source of the template name, or ErrNotFound.
+func (s *Store) Get(name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	source, ok := s.sources[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return source, nil
+}
+
+// Names returns the names of the stored templates, sorted.
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.sources))
+}
+
+// Put stores source as the template name, replacing any template of that
+// name. It reports whether the template is new.
+func (s *Store) Put(name, source string) (bool, error) {
+	if !ValidName(name) {
+		return false, errors.New("invalid template name")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(s.path(name), []byte(source), 0600); err != nil {
+		return false, err
+	}
+	_, exists := s.sources[name]
+	s.sources[name] = source
+	return !exists, nil
+}
+
+// Delete removes the template name, or returns ErrNotFound.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sources[name]; !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	delete(s.sources, name)
+	return nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+ext)
+}
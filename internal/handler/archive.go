@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// maxArchiveFiles and maxArchiveSize bound what an uploaded archive may
+	// expand to, so that a small zip cannot fill the disk.
+	maxArchiveFiles = 1000
+	maxArchiveSize  = 100 << 20
+
+	defaultArchiveMain = "main.tex"
+)
+
+// unpackArchive decodes a base64 zip archive and returns its main file as the
+// source and every other file by its path. Paths that would escape the job
+// directory are rejected.
+func unpackArchive(encoded, main string) (string, map[string][]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, badRequest("invalid archive: not base64")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, badRequest("invalid archive: not a zip file")
+	}
+	if len(zr.File) > maxArchiveFiles {
+		return "", nil, badRequest(fmt.Sprintf("invalid archive: more than %d files", maxArchiveFiles))
+	}
+
+	files := map[string][]byte{}
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(f.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return "", nil, badRequest("invalid archive: path outside the archive: " + f.Name)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return "", nil, badRequest("invalid archive: cannot read " + f.Name)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxArchiveSize-total+1))
+		rc.Close()
+		if err != nil {
+			return "", nil, badRequest("invalid archive: cannot read " + f.Name)
+		}
+		if total += int64(len(content)); total > maxArchiveSize {
+			return "", nil, badRequest(fmt.Sprintf("invalid archive: expands to more than %d MB", maxArchiveSize>>20))
+		}
+		files[name] = content
+	}
+
+	source, ok := files[main]
+	if !ok {
+		return "", nil, badRequest(fmt.Sprintf("invalid archive: no %s", main))
+	}
+	delete(files, main)
+	return string(source), files, nil
+}
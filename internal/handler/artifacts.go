@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"latex-renderer/internal/artifact"
+
+	"github.com/gin-gonic/gin"
+)
+
+// artifactTTL is how long outputs returned as links remain downloadable.
+const artifactTTL = time.Hour
+
+var artifacts = artifact.NewStore(filepath.Join(os.TempDir(), "latex-renderer-artifacts"), artifactTTL)
+
+// storeArtifact keeps data in the artifact store and returns its URL on the
+// service at baseURL.
+func storeArtifact(baseURL, name, contentType string, data []byte) (string, error) {
+	id, err := artifacts.Put(name, contentType, data)
+	if err != nil {
+		return "", internalError("cannot store artifact")
+	}
+	return baseURL + "/artifacts/" + id, nil
+}
+
+// activeContentTypes are the artifact types a browser would run scripts
+// in. Artifacts come from untrusted LaTeX and are served from the API's
+// origin, so these are sandboxed.
+var activeContentTypes = []string{"text/html", "image/svg+xml", "application/xhtml+xml", "application/xml", "text/xml"}
+
+// GetArtifact serves an output previously returned as a link.
+//
+//	@Summary		Download a rendered artifact
+//	@Description	Returns an output stored by a render that answered with links instead of inline content. Artifact ids are unguessable and need no Authorization header, so links can be used directly in <img> or <a> elements. Artifacts expire one hour after they are created. HTML, SVG and XML artifacts are served in a CSP sandbox, so scripts in them do not run.
+//	@Tags			artifacts
+//	@Produce		octet-stream
+//	@Param			id	path		string	true	"Artifact id"
+//	@Success		200	{file}		binary	"Artifact content"
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/artifacts/{id} [get]
+func GetArtifact(c *gin.Context) {
+	a, err := artifacts.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(time.Until(a.Expires).Seconds())))
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", a.Name))
+	c.Header("Content-Type", a.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	for _, t := range activeContentTypes {
+		if strings.HasPrefix(a.ContentType, t) {
+			c.Header("Content-Security-Policy", "sandbox")
+			break
+		}
+	}
+	c.File(a.Path)
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStylesheet serves the LaTeXML stylesheet that HTML rendered with
+// css=link references.
+//
+//	@Summary		Download the LaTeXML stylesheet
+//	@Description	Returns the LaTeXML stylesheet with the overrides of a theme, rewritten for a css_scope when asked. The URLs written by css=link carry a version parameter that changes with the stylesheet, so those responses are cacheable for a year; requests with a missing or outdated version are cacheable for an hour. Needs no Authorization header.
+//	@Tags			assets
+//	@Produce		text/css
+//	@Param			theme	query		string	false	"Theme name"	Enums(default, dark, journal)	default(default)
+//	@Param			scope	query		string	false	"css_scope the stylesheet is rewritten for"	Enums(none, class, shadow)	default(none)
+//	@Param			v		query		string	false	"Stylesheet version"
+//	@Success		200	{string}	string	"Stylesheet"
+//	@Success		304	"Not modified"
+//	@Failure		404	{object}	ErrorResponse
+//	@Router			/assets/latexml.css [get]
+func GetStylesheet(c *gin.Context) {
+	theme := c.DefaultQuery("theme", defaultTheme)
+	sheet, ok := stylesheets[theme]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "unknown theme: " + theme})
+		return
+	}
+
+	scope := c.DefaultQuery("scope", "none")
+	if !slices.Contains(cssScopes, scope) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "unknown scope: " + scope})
+		return
+	}
+
+	etag := `"` + sheet.version + "-" + scope + `"`
+	c.Header("ETag", etag)
+	if c.Query("v") == sheet.version {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		// The content behind an unversioned URL changes with the server.
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	if strings.Contains(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(sheet.scopedCSS(sheet.css, scope)))
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxBatchItems = 500
+	batchWorkers  = 4
+)
+
+// renderSlots bounds how many batch items and merge documents render at
+// once across all requests, so concurrent batches do not multiply the load.
+var renderSlots = make(chan struct{}, batchWorkers)
+
+// acquireRenderSlot waits for a free render slot, or for ctx to end.
+func acquireRenderSlot(ctx context.Context) error {
+	select {
+	case renderSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseRenderSlot() {
+	<-renderSlots
+}
+
+// BatchItem is one entry of a batch: a math expression or a whole document.
+type BatchItem struct {
+	Type string `json:"type" enums:"math,document" example:"math"`
+	// Math is the expression of a math item.
+	Math *MathReq `json:"math,omitempty"`
+	// Format is the output of a document item.
+	Format string `json:"format,omitempty" enums:"html,pdf,svg,png,jpeg,webp,thumbnail,epub,docx,odt,jats,tei" example:"html"`
+	// Document is the source, images and options of a document item.
+	Document *RenderReq `json:"document,omitempty"`
+}
+
+// BatchReq is a list of items rendered in a single request.
+type BatchReq struct {
+	Items []BatchItem `json:"items"`
+}
+
+// BatchResult is the outcome of one item, at the same index as in the request.
+type BatchResult struct {
+	Status      int    `json:"status" example:"200"`
+	ContentType string `json:"content_type,omitempty" example:"application/mathml+xml; charset=utf-8"`
+	// Body is the output, base64-encoded when Encoding is base64.
+	Body     string         `json:"body,omitempty"`
+	Encoding string         `json:"encoding,omitempty" enums:"utf8,base64" example:"utf8"`
+	Error    *ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchResponse holds one result per requested item, in request order.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// RenderBatch renders many math expressions or documents in one request.
+//
+//	@Summary		Render a batch of formulas or documents
+//	@Description	Renders every item in parallel, at most four at a time across all batch and merge requests, and returns one result per item, in request order. A failing item is reported in its own result and does not fail the batch. Text outputs are returned as-is, binary outputs (PDF, PNG) base64-encoded.
+//	@Tags			render
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string		true	"Bearer API key"
+//	@Param			request			body		BatchReq	true	"Items to render"
+//	@Success		200	{object}	BatchResponse
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Router			/render/batch [post]
+func RenderBatch(c *gin.Context) {
+	req, err := newBatchReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	results := make([]BatchResult, len(req.Items))
+	var wg sync.WaitGroup
+
+	for i, item := range req.Items {
+		if acquireRenderSlot(ctx) != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer releaseRenderSlot()
+			results[i] = renderBatchItem(ctx, item)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		// The client has gone; there is no one to answer.
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, BatchResponse{Results: results})
+}
+
+func newBatchReqFromContext(c *gin.Context) (*BatchReq, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "application/json" {
+		return nil, &apiError{status: http.StatusUnsupportedMediaType, msg: "unsupported content type: use application/json"}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, readBodyError(err)
+	}
+
+	var req BatchReq
+	if err := decodeStrict(body, &req); err != nil {
+		return nil, badRequest("invalid json body: " + err.Error())
+	}
+	if len(req.Items) == 0 {
+		return nil, badRequest("items is required")
+	}
+	if len(req.Items) > maxBatchItems {
+		return nil, badRequest(fmt.Sprintf("too many items: at most %d", maxBatchItems))
+	}
+	for _, item := range req.Items {
+		if item.Document != nil {
+			item.Document.baseURL = requestBaseURL(c)
+		}
+	}
+	return &req, nil
+}
+
+// renderBatchItem validates and renders a single item, folding any failure
+// into its result.
+func renderBatchItem(ctx context.Context, item BatchItem) BatchResult {
+	out, contentType, err := item.render(ctx)
+	if err != nil {
+		status, resp := errorStatus(err)
+		return BatchResult{Status: status, Error: &resp}
+	}
+
+	result := BatchResult{Status: http.StatusOK, ContentType: contentType}
+	if isTextOutput(contentType) && utf8.Valid(out) {
+		result.Body, result.Encoding = string(out), "utf8"
+	} else {
+		result.Body, result.Encoding = base64.StdEncoding.EncodeToString(out), "base64"
+	}
+	return result
+}
+
+func (item BatchItem) render(ctx context.Context) ([]byte, string, error) {
+	switch item.Type {
+	case "math":
+		if item.Math == nil {
+			return nil, "", badRequest("math is required for math items")
+		}
+		if err := item.Math.normalize(); err != nil {
+			return nil, "", err
+		}
+		return renderMath(ctx, item.Math)
+	case "document":
+		if item.Document == nil {
+			return nil, "", badRequest("document is required for document items")
+		}
+		render, ok := documentRenderers[item.Format]
+		if !ok {
+			return nil, "", badRequest("unknown format: " + item.Format)
+		}
+		if err := item.Document.validate(); err != nil {
+			return nil, "", err
+		}
+		if err := item.Document.convertInput(ctx); err != nil {
+			return nil, "", err
+		}
+		return render(ctx, item.Document)
+	default:
+		return nil, "", badRequest(fmt.Sprintf("invalid type %q: must be one of math, document", item.Type))
+	}
+}
+
+// isTextOutput reports whether contentType is safe to return unencoded in JSON.
+func isTextOutput(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "xml")
+}
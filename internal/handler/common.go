@@ -1,43 +1,359 @@
 package handler
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// maxBodySize caps every request body, whatever its content type.
+const maxBodySize = 20 << 20
+
+// RenderReq is the normalised render request, independent of how it was sent.
 type RenderReq struct {
-	Content string
-	Images  map[string]ImageInput
+	Content string                `json:"content" example:"\\documentclass{article}\\begin{document}Hello\\end{document}"`
+	Images  map[string]ImageInput `json:"images,omitempty"`
+	Options RenderOptions         `json:"options"`
+	// Metadata is written into PDF output.
+	Metadata *PDFMetadata `json:"metadata,omitempty"`
+	// PostProcess is applied to PDF output after compilation.
+	PostProcess *PostProcessing `json:"post_process,omitempty"`
+
+	// files are extra source files, by path relative to the job directory,
+	// such as the contents of an uploaded archive.
+	files map[string][]byte
+	// baseURL is the scheme and host the service is reached at, for the
+	// absolute links of output embedded on other origins.
+	baseURL string
+	// deadline is when the render runs out of time, set by its first step.
+	deadline time.Time
 }
 
 type ImageInput struct {
 	URL string `json:"url"`
 }
 
+// apiError is a failure carrying the HTTP status and ErrorResponse to answer with.
+type apiError struct {
+	status int
+	msg    string
+	detail string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func badRequest(msg string) error {
+	return &apiError{status: http.StatusBadRequest, msg: msg}
+}
+
+func internalError(msg string) error {
+	return &apiError{status: http.StatusInternalServerError, msg: msg}
+}
+
+// errorStatus splits err into the status and body to answer with; errors
+// that are not an apiError are internal.
+func errorStatus(err error) (int, ErrorResponse) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.status, ErrorResponse{Error: apiErr.msg, Detail: apiErr.detail}
+	}
+	return http.StatusInternalServerError, ErrorResponse{Error: err.Error()}
+}
+
+// abortWithError answers with the status and body carried by err.
+func abortWithError(c *gin.Context, err error) {
+	status, resp := errorStatus(err)
+	c.JSON(status, resp)
+}
+
+// newRenderReqFromContext builds a RenderReq from the request body. Raw TeX
+// (text/plain, application/x-tex), JSON and multipart bodies are accepted;
+// anything else is rejected with 415. Options may also be given as query
+// parameters, which is the only way to pass them with a raw body; options in
+// the body take precedence.
 func newRenderReqFromContext(c *gin.Context) (*RenderReq, error) {
-	content := c.PostForm("content")
-	if content == "" {
-		return nil, errors.New("content is required")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		mediaType = ""
 	}
 
-	images := map[string]ImageInput{}
-	imagesJSON := c.PostForm("images")
-	if imagesJSON != "" {
-		if err := json.Unmarshal([]byte(imagesJSON), &images); err != nil {
-			return nil, errors.New("invalid images json")
+	req := &RenderReq{baseURL: requestBaseURL(c)}
+	if err := c.ShouldBindQuery(&req.Options); err != nil {
+		return nil, badRequest("invalid options query")
+	}
+
+	switch mediaType {
+	case "text/plain", "application/x-tex":
+		err = req.readRaw(c)
+	case "application/json":
+		err = req.readJSON(c)
+	case "multipart/form-data", "application/x-www-form-urlencoded":
+		err = req.readForm(c)
+	default:
+		return nil, &apiError{
+			status: http.StatusUnsupportedMediaType,
+			msg:    "unsupported content type: use text/plain, application/x-tex, application/json or multipart/form-data",
 		}
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if err := req.convertInput(c.Request.Context()); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (req *RenderReq) readRaw(c *gin.Context) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return readBodyError(err)
+	}
+	if len(body) == 0 {
+		return badRequest("empty body")
+	}
 
-	return &RenderReq{
-		Content: content,
-		Images:  images,
-	}, nil
+	req.Content = string(body)
+	return nil
+}
+
+func (req *RenderReq) readJSON(c *gin.Context) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return readBodyError(err)
+	}
+	if len(body) == 0 {
+		return badRequest("empty body")
+	}
+
+	if err := decodeStrict(body, req); err != nil {
+		return badRequest("invalid json body: " + err.Error())
+	}
+	return nil
+}
+
+func (req *RenderReq) readForm(c *gin.Context) error {
+	if err := c.Request.ParseMultipartForm(maxBodySize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return badRequest("invalid form")
+	}
+
+	req.Content = c.PostForm("content")
+
+	if imagesJSON := c.PostForm("images"); imagesJSON != "" {
+		if err := json.Unmarshal([]byte(imagesJSON), &req.Images); err != nil {
+			return badRequest("invalid images json")
+		}
+	}
+
+	if optionsJSON := c.PostForm("options"); optionsJSON != "" {
+		if err := decodeStrict([]byte(optionsJSON), &req.Options); err != nil {
+			return badRequest("invalid options json: " + err.Error())
+		}
+	}
+
+	if metadataJSON := c.PostForm("metadata"); metadataJSON != "" {
+		if err := decodeStrict([]byte(metadataJSON), &req.Metadata); err != nil {
+			return badRequest("invalid metadata json: " + err.Error())
+		}
+	}
+
+	if postProcessJSON := c.PostForm("post_process"); postProcessJSON != "" {
+		if err := decodeStrict([]byte(postProcessJSON), &req.PostProcess); err != nil {
+			return badRequest("invalid post_process json: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// publicBaseURL is where clients reach the service, when a proxy or API
+// gateway in front of it makes the request's own host the wrong one.
+var publicBaseURL = strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+
+// requestBaseURL returns the scheme and host links in output should point
+// to: PUBLIC_BASE_URL, or else those the request was made to.
+func requestBaseURL(c *gin.Context) string {
+	if publicBaseURL != "" {
+		return publicBaseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// decodeStrict unmarshals data into v, rejecting unknown fields so that a
+// misspelt option fails loudly instead of being silently ignored.
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func readBodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &apiError{status: http.StatusRequestEntityTooLarge, msg: "body too large"}
+	}
+	return badRequest("cannot read body")
+}
+
+// validate applies the checks shared by every body format.
+func (req *RenderReq) validate() error {
+	if strings.TrimSpace(req.Content) == "" {
+		return badRequest("content is required")
+	}
+
+	for filename, img := range req.Images {
+		if !strings.HasPrefix(img.URL, "http://") && !strings.HasPrefix(img.URL, "https://") {
+			return badRequest("invalid image url: " + filename)
+		}
+	}
+
+	if err := req.Options.normalize(); err != nil {
+		return err
+	}
+	if req.Metadata != nil {
+		if err := req.Metadata.validate(req.Options); err != nil {
+			return err
+		}
+	}
+	if req.PostProcess != nil {
+		return req.PostProcess.normalize(req)
+	}
+	return nil
+}
+
+// newJobDir creates a private working directory for a single render, so that
+// concurrent requests never share intermediate files or images.
+func newJobDir(id string) (string, error) {
+	dir := filepath.Join(os.TempDir(), id)
+	return dir, os.Mkdir(dir, 0700)
+}
+
+// job is the working directory of one document render. Every file in it is
+// named after the job id, so job.path(".pdf") is the compiled PDF.
+type job struct {
+	id  string
+	dir string
+}
+
+// newJob creates a job directory holding req's source and images. Callers
+// must call cleanup once the output has been read.
+func newJob(req *RenderReq) (*job, error) {
+	id := uuid.NewString()
+	dir, err := newJobDir(id)
+	if err != nil {
+		return nil, internalError("cannot create job directory")
+	}
+	j := &job{id: id, dir: dir}
+
+	if err := os.WriteFile(j.path(".tex"), []byte(req.Content), 0600); err != nil {
+		j.cleanup()
+		return nil, internalError("cannot write tex file")
+	}
+
+	for name, data := range req.files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			j.cleanup()
+			return nil, internalError("cannot write source files")
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			j.cleanup()
+			return nil, internalError("cannot write source files")
+		}
+	}
+
+	if err := req.downloadImages(dir); err != nil {
+		j.cleanup()
+		return nil, internalError(err.Error())
+	}
+	return j, nil
+}
+
+func (j *job) path(ext string) string {
+	return filepath.Join(j.dir, j.id+ext)
+}
+
+func (j *job) cleanup() {
+	os.RemoveAll(j.dir)
+}
+
+// renderContext bounds a step of req's render by the timeout requested in
+// its options. The clock starts at the first step, so converting the input
+// and compiling it share one deadline.
+func renderContext(ctx context.Context, req *RenderReq) (context.Context, context.CancelFunc) {
+	if req.deadline.IsZero() {
+		req.deadline = time.Now().Add(time.Duration(req.Options.Timeout) * time.Second)
+	}
+	return context.WithDeadline(ctx, req.deadline)
+}
+
+// toolFailed describes a failed tool run: 504 when the render ran out of
+// time, 400 with the tool's diagnostics otherwise.
+func toolFailed(ctx context.Context, msg, detail string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &apiError{status: http.StatusGatewayTimeout, msg: "render timed out"}
+	}
+	return &apiError{status: http.StatusBadRequest, msg: msg, detail: detail}
+}
+
+// commandDetail returns the diagnostics of a failed TeX tool: its stderr, or
+// the error lines of logFile when stderr is empty.
+func commandDetail(stderr, logFile string) string {
+	if stderr != "" {
+		return stderr
+	}
+	if logBytes, err := os.ReadFile(logFile); err == nil {
+		return extractTexErrors(string(logBytes))
+	}
+	return ""
+}
+
+// zipFiles archives files under the given names, in order.
+func zipFiles(names, files []string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.Create(names[i])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 func (req *RenderReq) downloadImages(dir string) error {
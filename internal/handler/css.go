@@ -0,0 +1,407 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//go:embed static/css/LaTeXML.css
+var latexmlCSS string
+
+//go:embed static/css/themes/*.css
+var themeFiles embed.FS
+
+const (
+	defaultTheme = "default"
+
+	// stylesheetPath is where GetStylesheet serves the stylesheet that
+	// css=link references.
+	stylesheetPath = "/assets/latexml.css"
+)
+
+// stylesheet is the CSS of one theme: LaTeXML's base rules followed by the
+// theme's overrides, and a version that changes with its content.
+type stylesheet struct {
+	css     string
+	version string
+}
+
+// stylesheets is the theme registry, built from the embedded theme files.
+// The default theme is the base stylesheet alone.
+var stylesheets, themes = loadStylesheets()
+
+func loadStylesheets() (map[string]stylesheet, []string) {
+	sheets := map[string]stylesheet{defaultTheme: newStylesheet(latexmlCSS)}
+	files, _ := themeFiles.ReadDir("static/css/themes")
+	for _, f := range files {
+		overrides, err := themeFiles.ReadFile("static/css/themes/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+		name := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+		sheets[name] = newStylesheet(latexmlCSS + "\n" + string(overrides))
+	}
+
+	names := make([]string, 0, len(sheets))
+	for name := range sheets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return sheets, names
+}
+
+func newStylesheet(css string) stylesheet {
+	sum := sha256.Sum256([]byte(css))
+	return stylesheet{css: css, version: hex.EncodeToString(sum[:6])}
+}
+
+// scopedCSS returns the stylesheet rewritten for a css_scope.
+func (s stylesheet) scopedCSS(css, scope string) string {
+	switch scope {
+	case "class":
+		return scopeCSS(css, "."+scopeClass(s.version))
+	case "shadow":
+		return scopeCSS(css, ":host")
+	}
+	return css
+}
+
+// scopeClass is the wrapper class of css_scope=class. It is named after the
+// stylesheet version, so that fragments with different themes or from
+// different server versions never share it.
+func scopeClass(version string) string {
+	return "ltx-scope-" + version
+}
+
+// stylesheetURL is the versioned URL of a theme's stylesheet on the service
+// at baseURL. It is absolute, as fragments are embedded in pages of other
+// origins. The version lets the stylesheet be cached for good: a changed
+// stylesheet gets a new URL.
+func stylesheetURL(baseURL, theme, scope string) string {
+	url := baseURL + stylesheetPath + "?"
+	if theme != defaultTheme {
+		url += "theme=" + theme + "&"
+	}
+	if scope != "none" {
+		url += "scope=" + scope + "&"
+	}
+	return url + "v=" + stylesheets[theme].version
+}
+
+// applyCSS delivers the stylesheet of opts.Theme as opts.CSS asks: inlined
+// whole, inlined with only the rules that page uses, linked, or not at all.
+// With a css_scope, the fragment and its stylesheet are wrapped so that
+// neither styles leak into the host page nor its styles into the fragment.
+func applyCSS(page []byte, opts RenderOptions, baseURL string) []byte {
+	sheet := stylesheets[opts.Theme]
+
+	var style string
+	switch opts.CSS {
+	case "inline", "used":
+		css := sheet.css
+		if opts.CSS == "used" {
+			css = usedCSS(css, page)
+		}
+		style = fmt.Sprintf("<style>\n%s\n</style>\n", sheet.scopedCSS(css, opts.CSSScope))
+	case "link":
+		style = fmt.Sprintf("<link rel=\"stylesheet\" href=\"%s\">\n", html.EscapeString(stylesheetURL(baseURL, opts.Theme, opts.CSSScope)))
+	}
+
+	switch opts.CSSScope {
+	case "class":
+		return slices.Concat([]byte(fmt.Sprintf("<div class=\"%s\">\n%s", scopeClass(sheet.version), style)), page, []byte("</div>\n"))
+	case "shadow":
+		// A declarative shadow root: the browser attaches it to the host
+		// element while parsing, without script.
+		return slices.Concat([]byte("<div class=\"ltx-shadow-host\"><template shadowrootmode=\"open\">\n"+style), page, []byte("</template></div>\n"))
+	}
+	return addToHead(page, []byte(style))
+}
+
+// addToHead adds markup at the end of <head>, or ahead of the markup when
+// page is a fragment.
+func addToHead(page, markup []byte) []byte {
+	if i := bytes.Index(page, []byte("</head>")); i >= 0 {
+		return slices.Concat(page[:i], markup, page[i:])
+	}
+	return append(markup, page...)
+}
+
+var (
+	classAttr     = regexp.MustCompile(`\sclass\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	classSelector = regexp.MustCompile(`\.(-?[_A-Za-z][_A-Za-z0-9-]*)`)
+)
+
+// cssRule is a rule of a stylesheet: a style rule or an at-rule with its
+// declarations, a block at-rule such as @media holding nested rules, or a
+// statement at-rule such as @import.
+type cssRule struct {
+	prelude   string
+	body      string
+	rules     []cssRule
+	nested    bool
+	statement bool
+}
+
+// usedCSS returns the rules of css that can apply to page. A selector is
+// kept when every class it requires appears in page; selectors without
+// classes and at-rules other than conditional groups are always kept, so
+// the result may hold more than page needs but never less.
+func usedCSS(css string, page []byte) string {
+	classes := map[string]bool{}
+	for _, m := range classAttr.FindAllSubmatch(page, -1) {
+		for _, class := range strings.Fields(string(m[1]) + " " + string(m[2])) {
+			classes[class] = true
+		}
+	}
+
+	rules, _ := parseCSS(stripCSSComments(css), 0)
+	var b strings.Builder
+	writeCSS(&b, filterCSS(rules, classes), "")
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func filterCSS(rules []cssRule, classes map[string]bool) []cssRule {
+	var kept []cssRule
+	for _, r := range rules {
+		switch {
+		case r.nested:
+			if r.rules = filterCSS(r.rules, classes); len(r.rules) > 0 {
+				kept = append(kept, r)
+			}
+		case r.statement, strings.HasPrefix(r.prelude, "@"):
+			kept = append(kept, r)
+		default:
+			var selectors []string
+			for _, sel := range splitSelectors(r.prelude) {
+				if selectorUsed(sel, classes) {
+					selectors = append(selectors, sel)
+				}
+			}
+			if len(selectors) > 0 {
+				r.prelude = strings.Join(selectors, ", ")
+				kept = append(kept, r)
+			}
+		}
+	}
+	return kept
+}
+
+// selectorUsed reports whether every class that sel requires is in classes.
+// Classes inside attribute selectors and functional pseudo-classes such as
+// :not() are not required.
+func selectorUsed(sel string, classes map[string]bool) bool {
+	var required strings.Builder
+	depth := 0
+	for i := 0; i < len(sel); i++ {
+		switch c := sel[i]; c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '"', '\'':
+			i = skipCSSString(sel, i) - 1
+		default:
+			if depth == 0 {
+				required.WriteByte(c)
+			}
+		}
+	}
+	for _, m := range classSelector.FindAllStringSubmatch(required.String(), -1) {
+		if !classes[m[1]] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitSelectors splits a selector list at its top-level commas.
+func splitSelectors(list string) []string {
+	var selectors []string
+	depth, start := 0, 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '"', '\'':
+			i = skipCSSString(list, i) - 1
+		case ',':
+			if depth == 0 {
+				selectors = append(selectors, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(selectors, strings.TrimSpace(list[start:]))
+}
+
+// parseCSS parses the rules of css from position i up to the end of the
+// enclosing block, and returns them with the position after that block.
+func parseCSS(css string, i int) ([]cssRule, int) {
+	var rules []cssRule
+	start := i
+	for i < len(css) {
+		switch css[i] {
+		case '"', '\'':
+			i = skipCSSString(css, i)
+			continue
+		case ';':
+			if prelude := strings.TrimSpace(css[start:i]); prelude != "" {
+				rules = append(rules, cssRule{prelude: prelude, statement: true})
+			}
+		case '{':
+			prelude := strings.TrimSpace(css[start:i])
+			if conditionalGroup(prelude) {
+				nested, end := parseCSS(css, i+1)
+				rules = append(rules, cssRule{prelude: prelude, rules: nested, nested: true})
+				i, start = end, end
+				continue
+			}
+			end := blockEnd(css, i)
+			rules = append(rules, cssRule{prelude: prelude, body: strings.TrimSpace(css[i+1 : end])})
+			i = end
+		case '}':
+			return rules, i + 1
+		default:
+			i++
+			continue
+		}
+		i++
+		start = i
+	}
+	return rules, i
+}
+
+// conditionalGroup reports whether an at-rule's block holds rules rather
+// than declarations.
+func conditionalGroup(prelude string) bool {
+	for _, name := range []string{"@media", "@supports", "@container", "@layer"} {
+		if strings.HasPrefix(prelude, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockEnd returns the position of the brace closing the block opened at i.
+func blockEnd(css string, i int) int {
+	depth := 0
+	for i < len(css) {
+		switch css[i] {
+		case '"', '\'':
+			i = skipCSSString(css, i)
+			continue
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return len(css)
+}
+
+// skipCSSString returns the position after the string literal opened at i.
+func skipCSSString(css string, i int) int {
+	quote := css[i]
+	for i++; i < len(css); i++ {
+		switch css[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(css)
+}
+
+// stripCSSComments removes /* */ comments outside string literals.
+func stripCSSComments(css string) string {
+	var b strings.Builder
+	for i := 0; i < len(css); {
+		switch {
+		case css[i] == '"' || css[i] == '\'':
+			end := skipCSSString(css, i)
+			b.WriteString(css[i:end])
+			i = end
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 4
+		default:
+			b.WriteByte(css[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// rootSelector matches the compounds at the start of a selector that stand
+// for the whole page.
+var rootSelector = regexp.MustCompile(`^(?:(?:html|body|:root)(?:\s*>\s*|\s+|$))+`)
+
+// scopeCSS confines the style rules of css to the element matched by scope.
+// Selectors for the page root are moved onto the scope itself. Inside a
+// shadow root, whose stylesheet cannot reach the page anyway, the scope is
+// :host and other selectors only lose their root compounds; otherwise they
+// are prefixed with the scope.
+func scopeCSS(css, scope string) string {
+	rules, _ := parseCSS(stripCSSComments(css), 0)
+	var b strings.Builder
+	writeCSS(&b, scopeRules(rules, scope), "")
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func scopeRules(rules []cssRule, scope string) []cssRule {
+	for i, r := range rules {
+		switch {
+		case r.nested:
+			rules[i].rules = scopeRules(r.rules, scope)
+		case r.statement, strings.HasPrefix(r.prelude, "@"):
+		default:
+			selectors := splitSelectors(r.prelude)
+			for j, sel := range selectors {
+				rest := rootSelector.ReplaceAllString(sel, "")
+				switch {
+				case rest == "":
+					selectors[j] = scope
+				case scope == ":host":
+					selectors[j] = rest
+				default:
+					selectors[j] = scope + " " + rest
+				}
+			}
+			rules[i].prelude = strings.Join(selectors, ", ")
+		}
+	}
+	return rules
+}
+
+func writeCSS(b *strings.Builder, rules []cssRule, indent string) {
+	for _, r := range rules {
+		switch {
+		case r.statement:
+			fmt.Fprintf(b, "%s%s;\n", indent, r.prelude)
+		case r.nested:
+			fmt.Fprintf(b, "%s%s {\n", indent, r.prelude)
+			writeCSS(b, r.rules, indent+"  ")
+			fmt.Fprintf(b, "%s}\n", indent)
+		default:
+			fmt.Fprintf(b, "%s%s { %s }\n", indent, r.prelude, r.body)
+		}
+	}
+}
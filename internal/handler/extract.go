@@ -0,0 +1,395 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// latexmlNS is the namespace of LaTeXML's XML.
+const latexmlNS = "http://dlmf.nist.gov/LaTeXML"
+
+// sectionLevels are the sectioning elements of LaTeXML's XML that go into
+// the outline.
+var sectionLevels = map[string]bool{
+	"part": true, "chapter": true, "section": true, "subsection": true,
+	"subsubsection": true, "paragraph": true, "subparagraph": true,
+	"appendix": true, "bibliography": true, "index": true,
+}
+
+// Elements that are left out of the extracted text: math internals, the
+// number lists of numbered items, and markup with nothing to read.
+var skippedElements = map[string]bool{
+	"XMath": true, "MathBranch": true, "tags": true, "resource": true,
+	"graphics": true, "indexmark": true, "TOC": true, "ERROR": true,
+	"rdf": true, "navigation": true,
+}
+
+// Elements that start a line, and those that start a paragraph, of the
+// extracted text.
+var (
+	lineElements = map[string]bool{
+		"item": true, "bibitem": true, "tr": true, "break": true,
+		"creator": true, "date": true,
+	}
+	paragraphElements = map[string]bool{
+		"p": true, "para": true, "title": true, "subtitle": true, "caption": true,
+		"toctitle": true, "abstract": true, "equation": true, "equationgroup": true,
+		"quote": true, "listing": true, "figure": true, "table": true,
+		"float": true, "note": true, "theorem": true, "proof": true,
+		"itemize": true, "enumerate": true, "description": true, "tabular": true,
+	}
+)
+
+// Extract returns the plain text and the outline of a LaTeX document.
+//
+//	@Summary		Extract text and outline from LaTeX
+//	@Description	Converts a full LaTeX document to LaTeXML's XML and returns its plain text, for search indexing, together with an outline of its sections, figure and table captions, labels and display equations. Math is written as its TeX source, or with math_text=alt as the linear alt text LaTeXML derives from the formula. Markdown input is converted first, as for the render endpoints. Alias of /v1/extract.
+//	@Tags			extract
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}; math_text applies here"
+//	@Success		200	{object}	Extraction
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		501	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/extract [post]
+func Extract(c *gin.Context) {
+	req, err := newRenderReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	extraction, err := extract(c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, extraction)
+}
+
+// extract converts req to LaTeXML's XML and reads the text and outline out
+// of it.
+func extract(ctx context.Context, req *RenderReq) (*Extraction, error) {
+	j, err := newJob(req)
+	if err != nil {
+		return nil, err
+	}
+	defer j.cleanup()
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	if err := latexmlXML(ctx, j, req); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(j.path(".xml"))
+	if err != nil {
+		return nil, internalError("cannot read output")
+	}
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return nil, &apiError{status: http.StatusInternalServerError, msg: "cannot parse latexml output", detail: err.Error()}
+	}
+
+	x := &extractor{mathText: req.Options.MathText}
+	x.extraction.Outline = Outline{
+		Sections:  []OutlineSection{},
+		Figures:   []OutlineFloat{},
+		Tables:    []OutlineFloat{},
+		Equations: []OutlineEquation{},
+	}
+	x.walk(root)
+	x.extraction.Text = x.text.String()
+	return &x.extraction, nil
+}
+
+// xmlNode is an element of a parsed XML document. Its children are
+// *xmlNode elements and string character data, in document order.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []any
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// child returns the first child element of n named local.
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.children {
+		if e, ok := c.(*xmlNode); ok && e.name.Local == local {
+			return e
+		}
+	}
+	return nil
+}
+
+// parseXMLTree reads data into a tree of xmlNodes and returns its root.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	doc := &xmlNode{}
+	stack := []*xmlNode{doc}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, attrs: t.Attr}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.children = append(parent.children, string(t))
+		}
+	}
+	for _, c := range doc.children {
+		if e, ok := c.(*xmlNode); ok {
+			return e, nil
+		}
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+// extractor walks LaTeXML's XML, writing the text and collecting the
+// outline.
+type extractor struct {
+	mathText   string
+	text       textWriter
+	extraction Extraction
+}
+
+func (x *extractor) walk(n *xmlNode) {
+	local := n.name.Local
+	if n.name.Space != latexmlNS || skippedElements[local] {
+		return
+	}
+
+	switch {
+	case local == "Math":
+		x.text.write(x.math(n))
+		return
+	case local == "tag":
+		// Tags left in titles and captions number them, as in "2.1 Results".
+		x.text.write(nodeText(n, x.mathText, true) + n.attr("close"))
+		return
+	case sectionLevels[local]:
+		x.extraction.Outline.Sections = append(x.extraction.Outline.Sections, OutlineSection{
+			Level:  local,
+			Number: refNumber(n),
+			Title:  titleText(n, x.mathText),
+			Labels: labels(n),
+		})
+	case local == "figure" || local == "table" || local == "float":
+		x.float(n)
+	case local == "equation":
+		x.equation(n)
+	case local == "title" && x.extraction.Title == "" && len(x.extraction.Outline.Sections) == 0:
+		x.extraction.Title = nodeText(n, x.mathText, false)
+	case local == "personname":
+		x.extraction.Authors = append(x.extraction.Authors, nodeText(n, x.mathText, false))
+	}
+
+	x.text.boundary(local)
+	for _, c := range n.children {
+		switch c := c.(type) {
+		case *xmlNode:
+			x.walk(c)
+		case string:
+			x.text.write(c)
+		}
+	}
+	x.text.boundary(local)
+}
+
+func (x *extractor) float(n *xmlNode) {
+	item := OutlineFloat{Number: refNumber(n), Labels: labels(n)}
+	if caption := n.child("caption"); caption != nil {
+		item.Caption = nodeText(caption, x.mathText, false)
+	}
+	if n.name.Local == "table" {
+		x.extraction.Outline.Tables = append(x.extraction.Outline.Tables, item)
+	} else {
+		x.extraction.Outline.Figures = append(x.extraction.Outline.Figures, item)
+	}
+}
+
+func (x *extractor) equation(n *xmlNode) {
+	eq := OutlineEquation{Number: refNumber(n), Labels: labels(n)}
+	// An equation holds its Math directly or, as a row of an alignment,
+	// inside a MathFork whose first Math is the whole row.
+	var find func(*xmlNode) *xmlNode
+	find = func(n *xmlNode) *xmlNode {
+		for _, c := range n.children {
+			e, ok := c.(*xmlNode)
+			if !ok || skippedElements[e.name.Local] {
+				continue
+			}
+			if e.name.Local == "Math" {
+				return e
+			}
+			if m := find(e); m != nil {
+				return m
+			}
+		}
+		return nil
+	}
+	if m := find(n); m != nil {
+		eq.TeX = m.attr("tex")
+		eq.Text = m.attr("text")
+	}
+	x.extraction.Outline.Equations = append(x.extraction.Outline.Equations, eq)
+}
+
+// math returns the text of a formula: its TeX source or LaTeXML's alt
+// text, falling back to the TeX where the formula was not parsed.
+func (x *extractor) math(n *xmlNode) string {
+	if x.mathText == "alt" {
+		if text := n.attr("text"); text != "" {
+			return text
+		}
+	}
+	return n.attr("tex")
+}
+
+// nodeText returns the text of n on one line, with its tags if withTags.
+func nodeText(n *xmlNode, mathText string, withTags bool) string {
+	x := &extractor{mathText: mathText}
+	var walk func(*xmlNode)
+	walk = func(n *xmlNode) {
+		for _, c := range n.children {
+			switch c := c.(type) {
+			case string:
+				x.text.write(c)
+			case *xmlNode:
+				switch {
+				case skippedElements[c.name.Local], c.name.Local == "tag" && !withTags:
+				case c.name.Local == "Math":
+					x.text.write(x.math(c))
+				default:
+					walk(c)
+				}
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(x.text.String()), " ")
+}
+
+func titleText(n *xmlNode, mathText string) string {
+	if title := n.child("title"); title != nil {
+		return nodeText(title, mathText, false)
+	}
+	return ""
+}
+
+// refNumber returns the number LaTeXML gave n, such as "2.1", or "".
+func refNumber(n *xmlNode) string {
+	tags := n.child("tags")
+	if tags == nil {
+		return ""
+	}
+	var first string
+	for _, c := range tags.children {
+		tag, ok := c.(*xmlNode)
+		if !ok || tag.name.Local != "tag" {
+			continue
+		}
+		text := nodeText(tag, "tex", true)
+		if tag.attr("role") == "refnum" {
+			return text
+		}
+		if first == "" && tag.attr("role") == "" {
+			first = text
+		}
+	}
+	return first
+}
+
+// labels returns the \label keys of n. LaTeXML records them as
+// "LABEL:key".
+func labels(n *xmlNode) []string {
+	var keys []string
+	for _, label := range strings.Fields(n.attr("labels")) {
+		keys = append(keys, strings.TrimPrefix(label, "LABEL:"))
+	}
+	return keys
+}
+
+// textWriter builds plain text, collapsing the whitespace of the XML into
+// single spaces and separating lines and paragraphs.
+type textWriter struct {
+	b strings.Builder
+	// sep is written before the next text: a space, a tab between table
+	// cells, or the newlines ending a line or paragraph.
+	sep string
+}
+
+// boundary marks the start or end of element, which may end a line or a
+// paragraph.
+func (w *textWriter) boundary(element string) {
+	switch {
+	case paragraphElements[element] || sectionLevels[element]:
+		w.sep = "\n\n"
+	case lineElements[element] && w.sep != "\n\n":
+		w.sep = "\n"
+	case element == "td" && !strings.HasPrefix(w.sep, "\n"):
+		w.sep = "\t"
+	}
+}
+
+func (w *textWriter) write(s string) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && w.sep == "" {
+			w.sep = " "
+		}
+		return
+	}
+	if w.b.Len() > 0 {
+		if w.sep == "" && isSpace(s[0]) {
+			w.sep = " "
+		}
+		w.b.WriteString(w.sep)
+	}
+	w.sep = ""
+	w.b.WriteString(strings.Join(fields, " "))
+	if isSpace(s[len(s)-1]) {
+		w.sep = " "
+	}
+}
+
+func (w *textWriter) String() string {
+	return w.b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
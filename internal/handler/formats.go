@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// renderers maps the {format} segment of /v1/render/{format} to its handler.
+var renderers = map[string]gin.HandlerFunc{
+	"html":      Render,
+	"pdf":       RenderPDF,
+	"svg":       RenderSVG,
+	"png":       RenderImage("png"),
+	"jpeg":      RenderImage("jpeg"),
+	"webp":      RenderImage("webp"),
+	"thumbnail": RenderThumbnail,
+	"epub":      RenderEPUB,
+	"docx":      RenderOffice("docx"),
+	"odt":       RenderOffice("odt"),
+	"jats":      RenderXML("jats"),
+	"tei":       RenderXML("tei"),
+}
+
+// documentRenderer renders a parsed request, returning the output and its
+// content type.
+type documentRenderer func(ctx context.Context, req *RenderReq) ([]byte, string, error)
+
+// documentRenderers maps an output format to the function producing it,
+// for callers that render without a gin handler, such as batches.
+var documentRenderers = map[string]documentRenderer{
+	"html": renderHTML,
+	"pdf":  renderPDFResponse,
+	"svg":  renderSVG,
+	"png":  imageRenderer("png"),
+	"jpeg": imageRenderer("jpeg"),
+	"webp": imageRenderer("webp"),
+	"thumbnail": func(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+		out, err := renderThumbnailOnly(ctx, req)
+		return out, "image/png", err
+	},
+	"epub": renderEPUB,
+	"docx": officeRenderer("docx"),
+	"odt":  officeRenderer("odt"),
+	"jats": xmlRenderer("jats"),
+	"tei":  xmlRenderer("tei"),
+}
+
+func imageRenderer(format string) documentRenderer {
+	return func(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+		return renderImage(ctx, req, format)
+	}
+}
+
+// RenderFormat dispatches a versioned render request to the handler of the
+// requested output format.
+//
+//	@Summary		Render LaTeX to the requested format
+//	@Description	Versioned render endpoint. Accepts the same bodies as /render (raw TeX, JSON or multipart); the JSON form is documented here. Options may also be passed as query parameters.
+//	@Tags			v1
+//	@Accept			json,plain,application/x-tex,mpfd
+//	@Produce		text/html,application/pdf,image/svg+xml,image/png,image/jpeg,image/webp,application/zip,application/epub+zip,application/vnd.openxmlformats-officedocument.wordprocessingml.document,application/vnd.oasis.opendocument.text,application/jats+xml,application/tei+xml,json
+//	@Param			Authorization	header		string		true	"Bearer API key"
+//	@Param			format			path		string		true	"Output format"	Enums(html, pdf, svg, png, jpeg, webp, thumbnail, epub, docx, odt, jats, tei)
+//	@Param			request			body		RenderReq	true	"Document, images and options"
+//	@Success		200	{file}		binary	"Rendered document"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		422	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		501	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/v1/render/{format} [post]
+func RenderFormat(c *gin.Context) {
+	render, ok := renderers[c.Param("format")]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "unknown format: " + c.Param("format")})
+		return
+	}
+	render(c)
+}
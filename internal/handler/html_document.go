@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultLang is the language of documents that declare none.
+const defaultLang = "en"
+
+var (
+	htmlTag      = regexp.MustCompile(`(?i)<html\b[^>]*>`)
+	htmlLang     = regexp.MustCompile(`(?i)\slang="[^"]*"`)
+	htmlTitle    = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+	htmlCharset  = regexp.MustCompile(`(?i)<meta[^>]*charset`)
+	htmlViewport = regexp.MustCompile(`(?i)<meta[^>]*name="viewport"`)
+
+	babelPackage    = regexp.MustCompile(`\\usepackage\s*(?:\[([^\]]*)\])?\s*\{babel\}`)
+	classOptions    = regexp.MustCompile(`\\documentclass\s*\[([^\]]*)\]`)
+	polyglossiaMain = regexp.MustCompile(`\\set(?:default|main)language\s*(?:\[[^\]]*\])?\s*\{(\w+)\}`)
+	texCommand      = regexp.MustCompile(`\\[A-Za-z]+\*?\s*|\\.`)
+	// texNote matches a \thanks or \footnote, whose text is not part of
+	// the title it is attached to.
+	texNote = regexp.MustCompile(`\\(?:thanks|footnote)\s*\{(?:[^{}]|\{[^{}]*\})*\}`)
+
+	// langTag matches a BCP 47 language tag such as "es" or "pt-BR".
+	langTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+// babelLanguages maps babel and polyglossia language names to BCP 47 tags.
+var babelLanguages = map[string]string{
+	"english": "en", "american": "en-US", "USenglish": "en-US", "british": "en-GB", "UKenglish": "en-GB",
+	"spanish": "es", "catalan": "ca", "galician": "gl", "basque": "eu",
+	"portuguese": "pt", "portuges": "pt", "brazil": "pt-BR", "brazilian": "pt-BR",
+	"french": "fr", "francais": "fr", "german": "de", "ngerman": "de", "austrian": "de-AT", "naustrian": "de-AT",
+	"italian": "it", "dutch": "nl", "danish": "da", "swedish": "sv", "norsk": "nb", "nynorsk": "nn", "finnish": "fi",
+	"polish": "pl", "czech": "cs", "slovak": "sk", "hungarian": "hu", "romanian": "ro", "croatian": "hr",
+	"russian": "ru", "ukrainian": "uk", "greek": "el", "turkish": "tr",
+	"hebrew": "he", "arabic": "ar", "japanese": "ja", "chinese": "zh", "korean": "ko",
+}
+
+// documentLang returns the language of a LaTeX source: its polyglossia main
+// language, or babel's main language, which is the last one loaded.
+func documentLang(source string) string {
+	if m := polyglossiaMain.FindStringSubmatch(source); m != nil {
+		if lang, ok := babelLanguages[m[1]]; ok {
+			return lang
+		}
+	}
+
+	m := babelPackage.FindStringSubmatch(source)
+	if m == nil {
+		return defaultLang
+	}
+	options := m[1]
+	if class := classOptions.FindStringSubmatch(source); class != nil {
+		// Class options are global, and babel's own options come after them.
+		options = class[1] + "," + options
+	}
+	names := strings.Split(options, ",")
+	for _, name := range slices.Backward(names) {
+		name = strings.TrimSpace(name)
+		if main, ok := strings.CutPrefix(name, "main="); ok {
+			if lang, ok := babelLanguages[main]; ok {
+				return lang
+			}
+		}
+	}
+	for _, name := range slices.Backward(names) {
+		if lang, ok := babelLanguages[strings.TrimSpace(name)]; ok {
+			return lang
+		}
+	}
+	return defaultLang
+}
+
+// completeDocument turns LaTeXML's whole-document output into a shareable
+// HTML5 page: doctype, lang attribute, title and meta tags.
+func completeDocument(page []byte, req *RenderReq) []byte {
+	if !bytes.HasPrefix(bytes.TrimSpace(page), []byte("<!DOCTYPE")) {
+		page = append([]byte("<!DOCTYPE html>\n"), page...)
+	}
+
+	lang := req.Options.Lang
+	if lang == "" {
+		lang = documentLang(req.Content)
+	}
+	page = htmlTag.ReplaceAllFunc(page, func(tag []byte) []byte {
+		tag = htmlLang.ReplaceAll(tag, nil)
+		return slices.Concat(tag[:len(tag)-1], []byte(fmt.Sprintf(` lang="%s">`, lang)))
+	})
+
+	meta := req.Metadata
+	if meta == nil {
+		meta = &PDFMetadata{}
+	}
+
+	title := meta.Title
+	if m := htmlTitle.FindSubmatch(page); title == "" && (m == nil || len(bytes.TrimSpace(m[1])) == 0) {
+		if m := texTitle.FindStringSubmatch(req.Content); m != nil {
+			title = plainText(m[1])
+		}
+	}
+	if title != "" {
+		element := []byte("<title>" + html.EscapeString(title) + "</title>")
+		if htmlTitle.Match(page) {
+			page = htmlTitle.ReplaceAllLiteral(page, element)
+		} else {
+			page = insertInHead(page, append(element, '\n'))
+		}
+	}
+
+	var head strings.Builder
+	if !htmlCharset.Match(page) {
+		head.WriteString(`<meta charset="utf-8">` + "\n")
+	}
+	if !htmlViewport.Match(page) {
+		head.WriteString(`<meta name="viewport" content="width=device-width, initial-scale=1">` + "\n")
+	}
+	writeMeta := func(name, content string) {
+		if content != "" {
+			fmt.Fprintf(&head, "<meta name=\"%s\" content=\"%s\">\n", html.EscapeString(name), html.EscapeString(content))
+		}
+	}
+	writeMeta("author", meta.Author)
+	writeMeta("description", meta.Subject)
+	writeMeta("keywords", strings.Join(meta.Keywords, ", "))
+	keys := make([]string, 0, len(meta.Custom))
+	for key := range meta.Custom {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		writeMeta(key, meta.Custom[key])
+	}
+	return insertInHead(page, []byte(head.String()))
+}
+
+// insertInHead adds markup at the end of <head>, or leaves page unchanged
+// when it has none.
+func insertInHead(page, markup []byte) []byte {
+	if i := bytes.Index(page, []byte("</head>")); i >= 0 {
+		return slices.Concat(page[:i], markup, page[i:])
+	}
+	return page
+}
+
+// plainText strips TeX commands, grouping and notes from a short piece of
+// text, such as a \title, leaving the words.
+func plainText(tex string) string {
+	text := texNote.ReplaceAllString(tex, "")
+	text = texCommand.ReplaceAllStringFunc(text, func(cmd string) string {
+		if cmd == `\\` {
+			return " "
+		}
+		if len(cmd) == 2 && !isLetter(cmd[1]) {
+			// An escaped character such as \& or \%.
+			return cmd[1:]
+		}
+		return ""
+	})
+	text = strings.NewReplacer("{", "", "}", "", "~", " ").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
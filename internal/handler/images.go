@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"maps"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	imgTag  = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	srcAttr = regexp.MustCompile(`\ssrc="([^"]*)"`)
+)
+
+// rewriteLocalImages replaces the src of every <img> that points to a file of
+// dir with what fn returns for that file.
+func rewriteLocalImages(page []byte, dir string, fn func(file string) string) []byte {
+	return imgTag.ReplaceAllFunc(page, func(tag []byte) []byte {
+		src := srcAttr.FindSubmatch(tag)
+		if src == nil || strings.Contains(string(src[1]), ":") {
+			return tag
+		}
+		// Clean against the root so that the file cannot be outside dir.
+		file := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+string(src[1]))))
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			return tag
+		}
+		return srcAttr.ReplaceAllLiteral(tag, []byte(` src="`+fn(file)+`"`))
+	})
+}
+
+// keepImages makes the images that latexmlpost wrote into dir, which is
+// deleted with the job, outlive it as mode asks: inline rewrites their src to
+// data URIs and links to absolute artifact URLs on the service at baseURL.
+// zip rewrites it to the path of the image relative to the page, and returns
+// the files to pack next to the page by that path.
+func keepImages(page []byte, dir, mode, baseURL string) ([]byte, map[string]string, error) {
+	urls := map[string]string{}
+	files := map[string]string{}
+	var failed error
+	page = rewriteLocalImages(page, dir, func(file string) string {
+		if url, ok := urls[file]; ok {
+			return url
+		}
+		var url string
+		switch mode {
+		case "inline":
+			data, err := os.ReadFile(file)
+			if err != nil {
+				failed = internalError("cannot read image")
+				return ""
+			}
+			url = "data:" + imageType(file) + ";base64," + base64.StdEncoding.EncodeToString(data)
+		case "links":
+			data, err := os.ReadFile(file)
+			if err != nil {
+				failed = internalError("cannot read image")
+				return ""
+			}
+			if url, err = storeArtifact(baseURL, filepath.Base(file), imageType(file), data); err != nil {
+				failed = err
+				return ""
+			}
+		case "zip":
+			rel, _ := filepath.Rel(dir, file)
+			url = filepath.ToSlash(rel)
+			files[url] = file
+		}
+		urls[file] = url
+		return url
+	})
+	return page, files, failed
+}
+
+func imageType(file string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(file)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// zipOutput archives HTML pages under their names, in order, followed by
+// files at their relative paths.
+func zipOutput(names []string, pages [][]byte, files map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, data []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	for i, name := range names {
+		if err := add(name, pages[i]); err != nil {
+			return nil, internalError("cannot archive output")
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		data, err := os.ReadFile(files[name])
+		if err != nil {
+			return nil, internalError("cannot read image")
+		}
+		if err := add(name, data); err != nil {
+			return nil, internalError("cannot archive output")
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, internalError("cannot archive output")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+//go:embed static/templates/markdown/*.tex
+var markdownTemplateFiles embed.FS
+
+// convertInput turns a Markdown request into the LaTeX document the render
+// pipelines take, by running pandoc with the selected template. LaTeX
+// requests are left as they are.
+func (req *RenderReq) convertInput(ctx context.Context) error {
+	if req.Options.InputFormat != "markdown" {
+		return nil
+	}
+	pandoc, err := exec.LookPath("pandoc")
+	if err != nil {
+		return &apiError{status: http.StatusNotImplemented, msg: "input_format=markdown requires pandoc"}
+	}
+
+	template, err := markdownTemplateFiles.ReadFile("static/templates/markdown/" + req.Options.Template + ".tex")
+	if err != nil {
+		return internalError("cannot read template")
+	}
+	dir, err := newJobDir(uuid.NewString())
+	if err != nil {
+		return internalError("cannot create job directory")
+	}
+	defer os.RemoveAll(dir)
+	templateFile := filepath.Join(dir, "template.tex")
+	if err := os.WriteFile(templateFile, template, 0600); err != nil {
+		return internalError("cannot write template")
+	}
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, pandoc, markdownArgs(templateFile, req.Options)...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(req.Content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return toolFailed(ctx, "markdown conversion failed", stderr.String())
+	}
+	req.Content = stdout.String()
+	return nil
+}
+
+// markdownArgs builds the pandoc command line converting Markdown, read from
+// stdin, to a LaTeX document with templateFile. Title, author and date are
+// passed as metadata, which pandoc escapes for LaTeX and which overrides a
+// YAML block in the document.
+func markdownArgs(templateFile string, opts RenderOptions) []string {
+	to := "latex"
+	if opts.Template == "beamer" {
+		to = "beamer"
+	}
+	args := []string{"--from=markdown", "--to=" + to, "--standalone", "--template=" + templateFile, "--no-highlight"}
+	if opts.Template == "report" {
+		args = append(args, "--top-level-division=chapter")
+	}
+	for _, v := range [][2]string{{"title", opts.Title}, {"author", opts.Author}, {"date", opts.Date}} {
+		if v[1] != "" {
+			args = append(args, "--metadata="+v[0]+":"+v[1])
+		}
+	}
+	return args
+}
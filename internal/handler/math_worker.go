@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+//go:embed static/perl/latexml_math.pl
+var latexmlMathScript string
+
+// mathWorkers is how many persistent LaTeXML math processes may run at once.
+const mathWorkers = 2
+
+// mathStartTimeout bounds loading LaTeXML into a new worker, which takes far
+// longer than converting a snippet and is not charged to the request.
+const mathStartTimeout = 2 * time.Minute
+
+// mathPool holds the idle math workers. A nil entry is a free slot whose
+// worker has not been started yet, or was killed after a failure.
+var mathPool = func() chan *mathWorker {
+	pool := make(chan *mathWorker, mathWorkers)
+	for range mathWorkers {
+		pool <- nil
+	}
+	return pool
+}()
+
+// mathWorker is a long-lived perl process running latexml_math.pl, so that
+// LaTeXML is loaded once instead of once per snippet.
+type mathWorker struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   *bufio.Reader
+	killOnce sync.Once
+}
+
+type mathWorkerRequest struct {
+	TeX string `json:"tex"`
+}
+
+type mathWorkerResponse struct {
+	Ready      bool   `json:"ready"`
+	Result     string `json:"result"`
+	StatusCode int    `json:"status_code"`
+	Log        string `json:"log"`
+}
+
+// latexmlError is the LaTeXML status code from which a conversion has failed.
+const latexmlError = 2
+
+// StartMathWorkers fills the math pool, so the first requests do not wait
+// for LaTeXML to load. A worker that fails to start is retried on demand.
+func StartMathWorkers() {
+	for range mathWorkers {
+		w := <-mathPool
+		if w == nil {
+			w, _ = startMathWorker()
+		}
+		mathPool <- w
+	}
+}
+
+// convertMathML converts a math-mode TeX snippet to MathML on a pooled worker.
+// An invalid snippet fails with the LaTeXML log as the error detail. The
+// wait for a worker and the conversion each get mathTimeout; starting a new
+// worker has its own, longer, timeout.
+func convertMathML(ctx context.Context, tex string) (string, error) {
+	wait, cancel := context.WithTimeout(ctx, mathTimeout)
+	defer cancel()
+	var w *mathWorker
+	select {
+	case w = <-mathPool:
+	case <-wait.Done():
+		return "", toolFailed(wait, "math render failed", "")
+	}
+
+	if w == nil {
+		var err error
+		if w, err = startMathWorker(); err != nil {
+			mathPool <- nil
+			return "", err
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, mathTimeout)
+	defer cancel()
+	resp, err := w.roundTrip(ctx, mathWorkerRequest{TeX: tex})
+	if err != nil {
+		w.kill()
+		mathPool <- nil
+		if ctx.Err() != nil {
+			return "", toolFailed(ctx, "math render failed", "")
+		}
+		return "", internalError("math worker failed")
+	}
+	mathPool <- w
+
+	if resp.StatusCode >= latexmlError || resp.Result == "" {
+		return "", &apiError{status: http.StatusBadRequest, msg: "math render failed", detail: resp.Log}
+	}
+	return resp.Result, nil
+}
+
+func startMathWorker() (*mathWorker, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mathStartTimeout)
+	defer cancel()
+
+	cmd := exec.Command("perl", "-e", latexmlMathScript)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, internalError("cannot start math worker")
+	}
+
+	w := &mathWorker{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}
+	ready, err := w.read(ctx)
+	if err != nil || !ready.Ready {
+		w.kill()
+		if ctx.Err() != nil {
+			return nil, toolFailed(ctx, "math worker did not start", "")
+		}
+		return nil, internalError("math worker did not start")
+	}
+	return w, nil
+}
+
+// roundTrip sends one request and waits for its response. The worker is
+// killed by the caller on error, since its stream position is then unknown.
+func (w *mathWorker) roundTrip(ctx context.Context, req mathWorkerRequest) (*mathWorkerResponse, error) {
+	line, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.stdin.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return w.read(ctx)
+}
+
+// read waits for the next response line, killing the worker if ctx expires
+// first so that the blocked read returns.
+func (w *mathWorker) read(ctx context.Context) (*mathWorkerResponse, error) {
+	type result struct {
+		resp *mathWorkerResponse
+		err  error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		line, err := w.stdout.ReadBytes('\n')
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		var resp mathWorkerResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			done <- result{err: err}
+			return
+		}
+		done <- result{resp: &resp}
+	}()
+
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		w.kill()
+		return nil, ctx.Err()
+	}
+}
+
+func (w *mathWorker) kill() {
+	w.killOnce.Do(func() {
+		w.stdin.Close()
+		w.cmd.Process.Kill()
+		go w.cmd.Wait()
+	})
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxMergeDocuments = 200
+
+// MergeDocument is one document of a merge: inline source or a base64 zip
+// archive, each compiled with its own options.
+type MergeDocument struct {
+	RenderReq
+	// Title labels the document's bookmark. It defaults to the metadata
+	// title, then to the \title of the source.
+	Title string `json:"title,omitempty" example:"On Computable Numbers"`
+	// Archive is a base64-encoded zip holding the source and its files, used
+	// instead of content.
+	Archive string `json:"archive,omitempty"`
+	// Main is the archive entry to compile.
+	Main string `json:"main,omitempty" example:"main.tex"`
+}
+
+// MergeReq is an ordered list of documents merged into one PDF.
+type MergeReq struct {
+	Documents []MergeDocument `json:"documents"`
+	// PageNumbers stamps continuous page numbers over the merged PDF.
+	PageNumbers *Stamp `json:"page_numbers,omitempty"`
+	// SkipFailed merges the documents that compiled when others failed,
+	// instead of failing the request.
+	SkipFailed bool `json:"skip_failed,omitempty" example:"false"`
+	// Response returns the merged PDF, or a MergeReport.
+	Response string `json:"response,omitempty" enums:"pdf,report" example:"pdf"`
+}
+
+// MergeReport is the response of a merge with response=report.
+type MergeReport struct {
+	// PDF is the base64-encoded merged document, absent when nothing was merged.
+	PDF       string                `json:"pdf,omitempty"`
+	Pages     int                   `json:"pages" example:"42"`
+	Documents []MergeDocumentResult `json:"documents"`
+}
+
+// MergeDocumentResult is the outcome of one document, in request order.
+type MergeDocumentResult struct {
+	Title  string `json:"title" example:"On Computable Numbers"`
+	Status int    `json:"status" example:"200"`
+	// StartPage is where the document begins in the merged PDF.
+	StartPage int            `json:"start_page,omitempty" example:"1"`
+	Pages     int            `json:"pages,omitempty" example:"12"`
+	Error     *ErrorResponse `json:"error,omitempty"`
+}
+
+// mergedDocument is a compiled document waiting to be merged.
+type mergedDocument struct {
+	pdf []byte
+}
+
+// RenderMerge compiles several documents and merges them into one PDF.
+//
+//	@Summary		Merge LaTeX documents into one PDF
+//	@Description	Compiles every document in parallel, sharing the batch limit of four renders at a time, each with its own engine and options, and merges the PDFs in request order with qpdf, pages unchanged, adding one bookmark per document. The result keeps the first document's metadata and PDF/A identification, so metadata is only accepted on the first document and pdfa must match across documents. page_numbers stamps continuous numbering over the result. A failing document fails the request with 422 listing every failure, unless skip_failed is set; with response=report the answer is a MergeReport giving each document's status and page range.
+//	@Tags			render
+//	@Accept			json
+//	@Produce		application/pdf,json
+//	@Param			Authorization	header		string		true	"Bearer API key"
+//	@Param			request			body		MergeReq	true	"Documents to merge"
+//	@Success		200	{file}		binary	"Merged PDF, or MergeReport JSON with response=report"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		422	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/merge [post]
+func RenderMerge(c *gin.Context) {
+	req, err := newMergeReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	results := make([]MergeDocumentResult, len(req.Documents))
+	docs := make([]*mergedDocument, len(req.Documents))
+	var wg sync.WaitGroup
+
+	for i := range req.Documents {
+		if acquireRenderSlot(ctx) != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer releaseRenderSlot()
+			results[i], docs[i] = compileMergeDocument(ctx, &req.Documents[i], i)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		// The client has gone; there is no one to answer.
+		c.Abort()
+		return
+	}
+
+	var failures []string
+	for _, r := range results {
+		if r.Error != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", r.Title, errorText(r.Error)))
+		}
+	}
+	abort := len(failures) > 0 && !req.SkipFailed
+
+	var merged []byte
+	var pages int
+	if !abort && len(failures) < len(req.Documents) {
+		if merged, pages, err = mergePDFs(ctx, docs, results, req.PageNumbers); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
+	if req.Response == "report" {
+		report := MergeReport{Pages: pages, Documents: results}
+		if merged != nil {
+			report.PDF = base64.StdEncoding.EncodeToString(merged)
+		}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if merged == nil {
+		abortWithError(c, &apiError{
+			status: http.StatusUnprocessableEntity,
+			msg:    fmt.Sprintf("%d of %d documents failed", len(failures), len(req.Documents)),
+			detail: strings.Join(failures, "\n"),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/pdf", merged)
+}
+
+func newMergeReqFromContext(c *gin.Context) (*MergeReq, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "application/json" {
+		return nil, &apiError{status: http.StatusUnsupportedMediaType, msg: "unsupported content type: use application/json"}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, readBodyError(err)
+	}
+
+	var req MergeReq
+	if err := decodeStrict(body, &req); err != nil {
+		return nil, badRequest("invalid json body: " + err.Error())
+	}
+	if len(req.Documents) == 0 {
+		return nil, badRequest("documents is required")
+	}
+	if len(req.Documents) > maxMergeDocuments {
+		return nil, badRequest(fmt.Sprintf("too many documents: at most %d", maxMergeDocuments))
+	}
+	if req.Response == "" {
+		req.Response = "pdf"
+	}
+	if err := oneOf("response", req.Response, responses); err != nil {
+		return nil, err
+	}
+	if req.PageNumbers != nil {
+		if err := req.PageNumbers.normalize(); err != nil {
+			return nil, err
+		}
+	}
+	// The merged file has one catalog, the first document's: only its
+	// metadata survives, and it only claims PDF/A if every page conforms.
+	for i, doc := range req.Documents[1:] {
+		if doc.Metadata != nil {
+			return nil, badRequest(fmt.Sprintf("documents[%d]: metadata applies to the merged file and is read from the first document only", i+1))
+		}
+		if doc.Options.PDFA != req.Documents[0].Options.PDFA {
+			return nil, badRequest(fmt.Sprintf("documents[%d]: pdfa must be the same for every document", i+1))
+		}
+	}
+	return &req, nil
+}
+
+// compileMergeDocument validates and compiles one document, folding any
+// failure into its result.
+func compileMergeDocument(ctx context.Context, doc *MergeDocument, index int) (MergeDocumentResult, *mergedDocument) {
+	fail := func(err error) (MergeDocumentResult, *mergedDocument) {
+		status, resp := errorStatus(err)
+		return MergeDocumentResult{Title: doc.title(index), Status: status, Error: &resp}, nil
+	}
+
+	if doc.Archive != "" {
+		if doc.Content != "" {
+			return fail(badRequest("use either content or archive, not both"))
+		}
+		main := defaultArchiveMain
+		if doc.Main != "" {
+			main = path.Clean(doc.Main)
+		}
+		source, files, err := unpackArchive(doc.Archive, main)
+		if err != nil {
+			return fail(err)
+		}
+		doc.Content, doc.files = source, files
+	}
+	if err := doc.validate(); err != nil {
+		return fail(err)
+	}
+	if err := doc.convertInput(ctx); err != nil {
+		return fail(err)
+	}
+	if doc.PostProcess != nil && doc.PostProcess.Encrypt != nil {
+		// qpdf cannot merge an encrypted PDF without its password.
+		return fail(badRequest("encrypt is not supported for merged documents"))
+	}
+
+	res, err := renderPDF(ctx, &doc.RenderReq)
+	if err != nil {
+		return fail(err)
+	}
+	// The merged file claims PDF/A, so every part of it must conform.
+	if res.PDFA != nil && !res.PDFA.Compliant {
+		return fail(pdfaFailed(res.PDFA))
+	}
+	return MergeDocumentResult{Title: doc.title(index), Status: http.StatusOK}, &mergedDocument{pdf: res.PDF}
+}
+
+// title is the document's bookmark label.
+func (doc *MergeDocument) title(index int) string {
+	switch {
+	case doc.Title != "":
+		return doc.Title
+	case doc.Metadata != nil && doc.Metadata.Title != "":
+		return doc.Metadata.Title
+	}
+	if m := texTitle.FindStringSubmatch(doc.Content); m != nil {
+		if title := plainText(m[1]); title != "" {
+			return title
+		}
+	}
+	return fmt.Sprintf("Document %d", index+1)
+}
+
+// mergeTimeout bounds merging and numbering, after the documents compiled.
+const mergeTimeout = maxTimeout * time.Second
+
+// mergePDFs concatenates the compiled documents in order with qpdf, adding a
+// bookmark per document, and stamps page numbers when asked. Pages are copied
+// as they are, and the first document's catalog is kept, with its metadata
+// and PDF/A identification. It fills in the page range of each result.
+func mergePDFs(ctx context.Context, docs []*mergedDocument, results []MergeDocumentResult, numbers *Stamp) ([]byte, int, error) {
+	id := uuid.NewString()
+	dir, err := newJobDir(id)
+	if err != nil {
+		return nil, 0, internalError("cannot create job directory")
+	}
+	j := &job{id: id, dir: dir}
+	defer j.cleanup()
+
+	ctx, cancel := context.WithTimeout(ctx, mergeTimeout)
+	defer cancel()
+
+	var files []string
+	var bookmarks []outlineEntry
+	page := 1
+	for i, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		file := filepath.Join(dir, fmt.Sprintf("doc-%d.pdf", i+1))
+		if err := os.WriteFile(file, doc.pdf, 0600); err != nil {
+			return nil, 0, internalError("cannot write document")
+		}
+		info, err := readPDFInfo(ctx, file)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		results[i].StartPage, results[i].Pages = page, info.Pages
+		bookmarks = append(bookmarks, outlineEntry{title: results[i].Title, page: page})
+		page += info.Pages
+		files = append(files, file)
+	}
+
+	// The outline is appended to a cross-reference table, so the merged
+	// file is written without object streams.
+	out := j.path(".pdf")
+	args := append([]string{"--object-streams=disable", files[0], "--pages"}, files...)
+	if err := runQPDF(ctx, append(args, "--", out)...); err != nil {
+		return nil, 0, err
+	}
+	if err := addOutline(ctx, out, bookmarks); err != nil {
+		return nil, 0, err
+	}
+
+	if numbers != nil {
+		step := &overlayStep{name: "page-numbers", draw: numbers.draw}
+		if out, err = step.Process(ctx, j, out); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	merged, err := os.ReadFile(out)
+	if err != nil {
+		return nil, 0, internalError("cannot read output")
+	}
+	return merged, page - 1, nil
+}
+
+// errorText joins an error and its detail into a single line.
+func errorText(resp *ErrorResponse) string {
+	if resp.Detail == "" {
+		return resp.Error
+	}
+	return resp.Error + ": " + strings.ReplaceAll(strings.TrimSpace(resp.Detail), "\n", " | ")
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// xmpFile is the name, inside the job directory, of the XMP packet embedded
+// by the metadata setup. It is fixed rather than named after the job so that
+// reproducible renders see the same source.
+const xmpFile = "metadata.xmp"
+
+var (
+	// customKey restricts custom field names to ones that are valid both as
+	// XML element names and as PDF names without escaping.
+	customKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+	// xmpCustomField matches a custom field in the XMP packet; pdfx is
+	// Adobe's namespace for document properties outside Dublin Core.
+	xmpCustomField = regexp.MustCompile(`<pdfx:([A-Za-z][A-Za-z0-9_]*)>([^<]*)</pdfx:`)
+)
+
+// PDFMetadata is the document information written into a rendered PDF.
+type PDFMetadata struct {
+	Title    string   `json:"title,omitempty" example:"On Computable Numbers"`
+	Author   string   `json:"author,omitempty" example:"Alan Turing"`
+	Subject  string   `json:"subject,omitempty" example:"Computability"`
+	Keywords []string `json:"keywords,omitempty" example:"turing machine,decision problem"`
+	// Custom holds additional XMP fields, in the pdfx namespace. Names must
+	// start with a letter and contain only letters, digits and underscores.
+	Custom map[string]string `json:"custom,omitempty"`
+}
+
+// DocumentInfo is the metadata read back from a produced PDF.
+type DocumentInfo struct {
+	Title        string            `json:"title,omitempty"`
+	Author       string            `json:"author,omitempty"`
+	Subject      string            `json:"subject,omitempty"`
+	Keywords     []string          `json:"keywords,omitempty"`
+	Creator      string            `json:"creator,omitempty" example:"LaTeX with hyperref"`
+	Producer     string            `json:"producer,omitempty" example:"pdfTeX-1.40.22"`
+	CreationDate string            `json:"creation_date,omitempty" example:"2024-01-01T00:00:00Z"`
+	ModDate      string            `json:"mod_date,omitempty" example:"2024-01-01T00:00:00Z"`
+	Custom       map[string]string `json:"custom,omitempty"`
+}
+
+func (m *PDFMetadata) validate(opts RenderOptions) error {
+	for key := range m.Custom {
+		if !customKey.MatchString(key) {
+			return badRequest(fmt.Sprintf("invalid metadata custom field %q: use letters, digits and underscores", key))
+		}
+	}
+	if len(m.Custom) > 0 && opts.PDFA != "" {
+		// pdfx writes the XMP packet of PDF/A output and has no custom fields.
+		return badRequest("metadata custom fields are not supported with pdfa")
+	}
+	return nil
+}
+
+// metadataSetup returns the preamble code that writes m into the PDF when
+// the document begins: through \hypersetup when the document loads hyperref,
+// which would otherwise overwrite the Info dictionary, and through the
+// engine's own primitive when it does not. Unless withXMP is false, it also
+// embeds the XMP packet written by writeXMP.
+func metadataSetup(m *PDFMetadata, engine string, withXMP bool) string {
+	var hyper []string
+	var info []string
+	add := func(hyperKey, infoKey, value string) {
+		if value == "" {
+			return
+		}
+		hyper = append(hyper, fmt.Sprintf("%s={%s}", hyperKey, texEscape(value)))
+		info = append(info, fmt.Sprintf("/%s %s", infoKey, pdfHexString(value)))
+	}
+	add("pdftitle", "Title", m.Title)
+	add("pdfauthor", "Author", m.Author)
+	add("pdfsubject", "Subject", m.Subject)
+	add("pdfkeywords", "Keywords", strings.Join(m.Keywords, ", "))
+
+	infoDict := strings.Join(info, " ")
+	var primitive, xmp string
+	switch engine {
+	case "pdflatex":
+		primitive = fmt.Sprintf(`\pdfinfo{%s}`, infoDict)
+		xmp = fmt.Sprintf(`\immediate\pdfobj stream attr{/Type /Metadata /Subtype /XML} file {%s}\pdfcatalog{/Metadata \the\pdflastobj\space 0 R}`, xmpFile)
+	case "lualatex":
+		primitive = fmt.Sprintf(`\pdfextension info{%s}`, infoDict)
+		xmp = fmt.Sprintf(`\immediate\pdfextension obj stream attr{/Type /Metadata /Subtype /XML} file {%s}\pdfextension catalog{/Metadata \the\pdffeedback lastobj\space 0 R}`, xmpFile)
+	case "xelatex":
+		primitive = fmt.Sprintf(`\special{pdf:docinfo <<%s>>}`, infoDict)
+		xmp = fmt.Sprintf(`\special{pdf:fstream @xmpmeta (%s) <</Type /Metadata /Subtype /XML>>}\special{pdf:put @catalog <</Metadata @xmpmeta>>}`, xmpFile)
+	}
+	if !withXMP {
+		xmp = ""
+	}
+
+	return fmt.Sprintf("\\makeatletter\n\\AtBeginDocument{\\@ifpackageloaded{hyperref}{\\hypersetup{%s}}{%s}%s}\n\\makeatother\n",
+		strings.Join(hyper, ","), primitive, xmp)
+}
+
+// writeXMP writes the XMP packet for m into the job directory.
+func writeXMP(j *job, m *PDFMetadata) error {
+	var b strings.Builder
+	esc := func(s string) string {
+		var buf strings.Builder
+		xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	}
+
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	b.WriteString(`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:pdfx="http://ns.adobe.com/pdfx/1.3/">` + "\n")
+	if m.Title != "" {
+		fmt.Fprintf(&b, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", esc(m.Title))
+	}
+	if m.Author != "" {
+		fmt.Fprintf(&b, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", esc(m.Author))
+	}
+	if m.Subject != "" {
+		fmt.Fprintf(&b, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", esc(m.Subject))
+	}
+	if len(m.Keywords) > 0 {
+		fmt.Fprintf(&b, "<pdf:Keywords>%s</pdf:Keywords>\n", esc(strings.Join(m.Keywords, ", ")))
+	}
+	keys := make([]string, 0, len(m.Custom))
+	for key := range m.Custom {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "<pdfx:%s>%s</pdfx:%s>\n", key, esc(m.Custom[key]), key)
+	}
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>\n")
+
+	if err := os.WriteFile(filepath.Join(j.dir, xmpFile), []byte(b.String()), 0600); err != nil {
+		return internalError("cannot write xmp metadata")
+	}
+	return nil
+}
+
+// documentInfo combines the Info dictionary reported by pdfinfo with the
+// custom fields of the PDF's XMP packet.
+func documentInfo(info *pdfInfo, pdf []byte) *DocumentInfo {
+	doc := &DocumentInfo{
+		Title:        info.Fields["Title"],
+		Author:       info.Fields["Author"],
+		Subject:      info.Fields["Subject"],
+		Creator:      info.Fields["Creator"],
+		Producer:     info.Fields["Producer"],
+		CreationDate: info.Fields["CreationDate"],
+		ModDate:      info.Fields["ModDate"],
+	}
+	for _, keyword := range strings.Split(info.Fields["Keywords"], ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			doc.Keywords = append(doc.Keywords, keyword)
+		}
+	}
+
+	// The XMP packet is usually stored compressed.
+	for _, m := range xmpCustomField.FindAllSubmatch(slices.Concat(pdf, inflateStreams(pdf)), -1) {
+		if doc.Custom == nil {
+			doc.Custom = map[string]string{}
+		}
+		doc.Custom[string(m[1])] = html.UnescapeString(string(m[2]))
+	}
+	return doc
+}
+
+// pdfHexString encodes s as a UTF-16BE PDF hex string, which needs no
+// escaping either in PDF or in TeX.
+func pdfHexString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// texSpecials maps the characters with a special meaning in TeX to text
+// that typesets them literally.
+var texSpecials = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`%`, `\%`,
+	`#`, `\#`,
+	`&`, `\&`,
+	`$`, `\$`,
+	`_`, `\_`,
+	`^`, `\textasciicircum{}`,
+	`~`, `\textasciitilde{}`,
+)
+
+// texEscape returns s as TeX text that typesets s literally.
+func texEscape(s string) string {
+	return texSpecials.Replace(s)
+}
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultTimeout = 20
+	maxTimeout     = 120
+	maxPasses      = 5
+
+	defaultDPI = 150
+	minDPI     = 72
+	maxDPI     = 600
+
+	defaultMaxDimension = 4096
+	maxDimension        = 10000
+
+	defaultThumbnailSize = 256
+	maxThumbnailSize     = 1024
+)
+
+var (
+	engines     = []string{"pdflatex", "xelatex", "lualatex"}
+	mathFormats = []string{"pmml", "cmml", "pmml+cmml", "cmml+pmml", "pmml+tex", "cmml+tex", "pmml+cmml+tex", "svg", "png"}
+	mathTexts   = []string{"tex", "alt"}
+	htmlModes   = []string{"fragment", "document"}
+	cssModes    = []string{"inline", "used", "link", "none"}
+	cssScopes   = []string{"none", "class", "shadow"}
+	htmlImages  = []string{"inline", "links", "zip"}
+	svgRoutes   = []string{"dvi", "pdf"}
+	svgFonts    = []string{"paths", "embed"}
+	cropModes   = []string{"auto", "content", "page"}
+	bundles     = []string{"zip", "links"}
+	responses   = []string{"pdf", "report"}
+	pdfaLevels  = []string{"1b", "2b"}
+
+	inputFormats      = []string{"latex", "markdown"}
+	markdownTemplates = []string{"article", "report", "beamer"}
+
+	// pageRanges matches a page selection such as "1,3-5".
+	pageRanges = regexp.MustCompile(`^[1-9][0-9]*(-[1-9][0-9]*)?(,[1-9][0-9]*(-[1-9][0-9]*)?)*$`)
+)
+
+// RenderOptions tunes how a document is compiled. Empty fields take the
+// defaults applied by normalize; options that do not apply to the requested
+// format are ignored.
+type RenderOptions struct {
+	// InputFormat is the language of content. Markdown, with $...$ math, is
+	// converted to LaTeX through Template before rendering.
+	InputFormat string `json:"input_format,omitempty" form:"input_format" enums:"latex,markdown" example:"latex"`
+	// Template is the document class Markdown is converted into.
+	Template string `json:"template,omitempty" form:"template" enums:"article,report,beamer" example:"article"`
+	// Title, Author and Date fill the template of Markdown input, over any
+	// YAML metadata block in the content.
+	Title  string `json:"title,omitempty" form:"title" example:"Lecture 3"`
+	Author string `json:"author,omitempty" form:"author" example:"Ada Lovelace"`
+	Date   string `json:"date,omitempty" form:"date" example:"2024-03-01"`
+	// Engine is the TeX engine used for PDF output.
+	Engine string `json:"engine,omitempty" form:"engine" enums:"pdflatex,xelatex,lualatex" example:"pdflatex"`
+	// Passes is how many times the engine runs, to resolve references and TOCs.
+	Passes int `json:"passes,omitempty" form:"passes" minimum:"1" maximum:"5" example:"1"`
+	// Timeout bounds the whole render, in seconds.
+	Timeout int `json:"timeout,omitempty" form:"timeout" minimum:"1" maximum:"120" example:"20"`
+	// MathFormat selects how HTML output shows math: Presentation or Content
+	// MathML, both as parallel markup with the first one primary, either one
+	// annotated with the TeX source (+tex), or SVG or PNG images.
+	MathFormat string `json:"math_format,omitempty" form:"math_format" enums:"pmml,cmml,pmml+cmml,cmml+pmml,pmml+tex,cmml+tex,pmml+cmml+tex,svg,png" example:"pmml"`
+	// MathText writes math in extracted text as its TeX source, or as the
+	// linear alt text LaTeXML derives from the parsed formula.
+	MathText string `json:"math_text,omitempty" form:"math_text" enums:"tex,alt" example:"tex"`
+	// HTMLMode returns an embeddable fragment or a whole HTML document.
+	HTMLMode string `json:"html_mode,omitempty" form:"html_mode" enums:"fragment,document" example:"fragment"`
+	// Lang is the BCP 47 language of a whole HTML document, e.g. "es".
+	// Empty means the babel or polyglossia main language, or "en".
+	Lang string `json:"lang,omitempty" form:"lang" example:"es"`
+	// CSS delivers the LaTeXML stylesheet of HTML output: inlined whole,
+	// inlined with only the rules the output uses, linked from
+	// /assets/latexml.css, or left out.
+	CSS string `json:"css,omitempty" form:"css" enums:"inline,used,link,none" example:"inline"`
+	// CSSScope keeps the stylesheet of an HTML fragment from styling the
+	// page it is embedded in: class prefixes every rule with a wrapper class
+	// and wraps the fragment in it, shadow wraps fragment and stylesheet in a
+	// declarative shadow root.
+	CSSScope string `json:"css_scope,omitempty" form:"css_scope" enums:"none,class,shadow" example:"none"`
+	// Theme is the stylesheet theme of HTML output, from the server's theme
+	// registry.
+	Theme string `json:"theme,omitempty" form:"theme" enums:"default,dark,journal" example:"default"`
+	// HTMLImages keeps the images of HTML output, such as figures, TikZ
+	// pictures and math images: embedded as data URIs, stored as artifacts
+	// and linked, or returned with the page in a zip.
+	HTMLImages string `json:"html_images,omitempty" form:"html_images" enums:"inline,links,zip" example:"inline"`
+	// Split breaks HTML output into one page per part, chapter, section or
+	// subsection. The pages and a manifest of them are bundled as Bundle
+	// says: a zip, or artifacts with the manifest as the response.
+	Split string `json:"split,omitempty" form:"split" enums:"part,chapter,section,subsection" example:"section"`
+	// Sanitize filters HTML output through an allow-list of HTML, MathML and
+	// SVG elements and attributes, and drops links with unsafe URL schemes,
+	// for embedding output of untrusted LaTeX.
+	Sanitize bool `json:"sanitize,omitempty" form:"sanitize" example:"false"`
+	// StripIDs also drops id attributes when sanitizing.
+	StripIDs bool `json:"strip_ids,omitempty" form:"strip_ids" example:"false"`
+	// Pages selects the pages of image output, e.g. "1,3-5". Empty means all.
+	Pages string `json:"pages,omitempty" form:"pages" example:"1-2"`
+	// SVGRoute converts to SVG from the engine's DVI output or from its PDF.
+	SVGRoute string `json:"svg_route,omitempty" form:"svg_route" enums:"dvi,pdf" example:"dvi"`
+	// SVGFonts draws glyphs as paths or embeds the fonts in the SVG.
+	SVGFonts string `json:"svg_fonts,omitempty" form:"svg_fonts" enums:"paths,embed" example:"paths"`
+	// Crop trims image output to its content or keeps the page size. auto
+	// crops standalone-class documents only.
+	Crop string `json:"crop,omitempty" form:"crop" enums:"auto,content,page" example:"auto"`
+	// DPI is the resolution of raster image output.
+	DPI int `json:"dpi,omitempty" form:"dpi" minimum:"72" maximum:"600" example:"150"`
+	// Transparent keeps the page background transparent in PNG and WebP output.
+	Transparent bool `json:"transparent,omitempty" form:"transparent" example:"false"`
+	// MaxDimension rejects raster output whose width or height, in pixels,
+	// would exceed it.
+	MaxDimension int `json:"max_dimension,omitempty" form:"max_dimension" minimum:"16" maximum:"10000" example:"4096"`
+	// Bundle returns several pages as a zip, or as JSON links to artifacts.
+	Bundle string `json:"bundle,omitempty" form:"bundle" enums:"zip,links" example:"zip"`
+	// Response returns the PDF itself, or a PDFReport JSON wrapping it with
+	// the extras requested below. For JATS and TEI, report returns an
+	// XMLReport with the validation diagnostics, and for DOCX and ODT an
+	// OfficeReport with the conversion warnings.
+	Response string `json:"response,omitempty" form:"response" enums:"pdf,report" example:"pdf"`
+	// Thumbnail adds a first-page PNG preview to a PDF report.
+	Thumbnail bool `json:"thumbnail,omitempty" form:"thumbnail" example:"false"`
+	// ThumbnailSize is the length, in pixels, of the thumbnail's longer side.
+	ThumbnailSize int `json:"thumbnail_size,omitempty" form:"thumbnail_size" minimum:"16" maximum:"1024" example:"256"`
+	// PDFA produces a PDF/A-1b or PDF/A-2b file and validates it. Empty means
+	// a plain PDF.
+	PDFA string `json:"pdfa,omitempty" form:"pdfa" enums:"1b,2b" example:"2b"`
+	// Reproducible makes PDF output byte-identical across renders of the same
+	// input: fixed timestamps and a trailer /ID derived from the source.
+	Reproducible bool `json:"reproducible,omitempty" form:"reproducible" example:"false"`
+	// SourceDateEpoch is the date, in Unix seconds, that reproducible renders
+	// are stamped with and that \today prints. Reproducible renders need it.
+	SourceDateEpoch int64 `json:"source_date_epoch,omitempty" form:"source_date_epoch" minimum:"1" example:"1700000000"`
+}
+
+// normalize fills in defaults and validates every option. It is the single
+// place where option values are checked.
+func (o *RenderOptions) normalize() error {
+	if o.InputFormat == "" {
+		o.InputFormat = "latex"
+	}
+	if o.InputFormat == "markdown" && o.Template == "" {
+		o.Template = "article"
+	}
+	if o.Engine == "" {
+		o.Engine = "pdflatex"
+	}
+	if o.Passes == 0 {
+		o.Passes = 1
+	}
+	if o.Timeout == 0 {
+		o.Timeout = defaultTimeout
+	}
+	if o.MathFormat == "" {
+		o.MathFormat = "pmml"
+	}
+	if o.MathText == "" {
+		o.MathText = "tex"
+	}
+	if o.HTMLMode == "" {
+		o.HTMLMode = "fragment"
+	}
+	if o.CSS == "" {
+		o.CSS = "inline"
+	}
+	if o.CSSScope == "" {
+		o.CSSScope = "none"
+	}
+	if o.Theme == "" {
+		o.Theme = defaultTheme
+	}
+	if o.HTMLImages == "" {
+		o.HTMLImages = "inline"
+	}
+	if o.SVGRoute == "" {
+		o.SVGRoute = "dvi"
+	}
+	if o.SVGFonts == "" {
+		o.SVGFonts = "paths"
+	}
+	if o.Crop == "" {
+		o.Crop = "auto"
+	}
+	if o.DPI == 0 {
+		o.DPI = defaultDPI
+	}
+	if o.MaxDimension == 0 {
+		o.MaxDimension = defaultMaxDimension
+	}
+	if o.Bundle == "" {
+		o.Bundle = "zip"
+	}
+	if o.Response == "" {
+		o.Response = "pdf"
+	}
+	if o.ThumbnailSize == 0 {
+		o.ThumbnailSize = defaultThumbnailSize
+	}
+
+	if err := oneOf("input_format", o.InputFormat, inputFormats); err != nil {
+		return err
+	}
+	if o.InputFormat == "markdown" {
+		if err := oneOf("template", o.Template, markdownTemplates); err != nil {
+			return err
+		}
+	} else if o.Template != "" || o.Title != "" || o.Author != "" || o.Date != "" {
+		return badRequest("template, title, author and date require input_format=markdown")
+	}
+	if err := oneOf("engine", o.Engine, engines); err != nil {
+		return err
+	}
+	if o.Passes < 1 || o.Passes > maxPasses {
+		return badRequest(fmt.Sprintf("invalid passes: must be between 1 and %d", maxPasses))
+	}
+	if o.Timeout < 1 || o.Timeout > maxTimeout {
+		return badRequest(fmt.Sprintf("invalid timeout: must be between 1 and %d seconds", maxTimeout))
+	}
+	if err := oneOf("math_format", o.MathFormat, mathFormats); err != nil {
+		return err
+	}
+	if err := oneOf("math_text", o.MathText, mathTexts); err != nil {
+		return err
+	}
+	if err := oneOf("html_mode", o.HTMLMode, htmlModes); err != nil {
+		return err
+	}
+	if o.Lang != "" && !langTag.MatchString(o.Lang) {
+		return badRequest(fmt.Sprintf("invalid lang %q: use a language tag such as es or pt-BR", o.Lang))
+	}
+	if err := oneOf("css", o.CSS, cssModes); err != nil {
+		return err
+	}
+	if err := oneOf("css_scope", o.CSSScope, cssScopes); err != nil {
+		return err
+	}
+	if o.CSSScope != "none" && o.HTMLMode != "fragment" {
+		return badRequest("css_scope requires html_mode=fragment")
+	}
+	if err := oneOf("theme", o.Theme, themes); err != nil {
+		return err
+	}
+	if err := oneOf("html_images", o.HTMLImages, htmlImages); err != nil {
+		return err
+	}
+	if o.Split != "" {
+		if err := oneOf("split", o.Split, splitLevels); err != nil {
+			return err
+		}
+		if o.HTMLImages == "zip" && o.Bundle == "links" {
+			return badRequest("html_images=zip requires bundle=zip with split")
+		}
+	}
+	if o.StripIDs && !o.Sanitize {
+		return badRequest("strip_ids requires sanitize")
+	}
+	if o.Pages != "" && !pageRanges.MatchString(o.Pages) {
+		return badRequest(fmt.Sprintf("invalid pages %q: use a list of pages and ranges such as 1,3-5", o.Pages))
+	}
+	if err := oneOf("svg_route", o.SVGRoute, svgRoutes); err != nil {
+		return err
+	}
+	if err := oneOf("svg_fonts", o.SVGFonts, svgFonts); err != nil {
+		return err
+	}
+	if err := oneOf("crop", o.Crop, cropModes); err != nil {
+		return err
+	}
+	if o.DPI < minDPI || o.DPI > maxDPI {
+		return badRequest(fmt.Sprintf("invalid dpi: must be between %d and %d", minDPI, maxDPI))
+	}
+	if o.MaxDimension < 16 || o.MaxDimension > maxDimension {
+		return badRequest(fmt.Sprintf("invalid max_dimension: must be between 16 and %d", maxDimension))
+	}
+	if err := oneOf("bundle", o.Bundle, bundles); err != nil {
+		return err
+	}
+	if err := oneOf("response", o.Response, responses); err != nil {
+		return err
+	}
+	if o.ThumbnailSize < 16 || o.ThumbnailSize > maxThumbnailSize {
+		return badRequest(fmt.Sprintf("invalid thumbnail_size: must be between 16 and %d", maxThumbnailSize))
+	}
+	if o.SourceDateEpoch < 0 {
+		return badRequest("invalid source_date_epoch: must not be negative")
+	}
+	if o.Reproducible && o.SourceDateEpoch == 0 {
+		return badRequest("reproducible requires source_date_epoch")
+	}
+	if o.PDFA != "" {
+		if err := oneOf("pdfa", o.PDFA, pdfaLevels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func oneOf(name, value string, allowed []string) error {
+	if !slices.Contains(allowed, value) {
+		return badRequest(fmt.Sprintf("invalid %s %q: must be one of %s", name, value, strings.Join(allowed, ", ")))
+	}
+	return nil
+}
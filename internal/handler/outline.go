@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// outlineEntry is a top-level bookmark: a title and the 1-based page it
+// opens.
+type outlineEntry struct {
+	title string
+	page  int
+}
+
+var (
+	trailerRoot = regexp.MustCompile(`/Root\s+(\d+)\s+(\d+)\s+R`)
+	trailerSize = regexp.MustCompile(`/Size\s+\d+`)
+	trailerPrev = regexp.MustCompile(`/Prev\s+\d+`)
+	// catalogOutline matches the outline entries of a catalog, replaced by
+	// the new outline.
+	catalogOutline = regexp.MustCompile(`/(Outlines\s+\d+\s+\d+\s+R|PageMode\s*/\w+)`)
+	// pageObject matches a page of qpdf --show-pages, as "page 1: 3 0 R".
+	pageObject = regexp.MustCompile(`(?m)^page (\d+): (\d+ \d+ R)`)
+	startXref  = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+)
+
+// addOutline gives the PDF at file an outline of entries, replacing any it
+// had. The outline is appended as an incremental update, so the pages,
+// fonts, links and metadata already in the file stay byte for byte. The
+// file must have a cross-reference table, not a stream, as qpdf writes with
+// --object-streams=disable.
+func addOutline(ctx context.Context, file string, entries []outlineEntry) error {
+	trailer, err := qpdfOutput(ctx, "--show-object=trailer", file)
+	if err != nil {
+		return err
+	}
+	root := trailerRoot.FindStringSubmatch(trailer)
+	if root == nil {
+		return internalError("cannot find pdf catalog")
+	}
+	catalog, err := qpdfOutput(ctx, "--show-object="+root[1]+","+root[2], file)
+	if err != nil {
+		return err
+	}
+	catalog = strings.TrimSpace(catalog)
+	if !strings.HasSuffix(catalog, ">>") {
+		return internalError("cannot read pdf catalog")
+	}
+	shown, err := qpdfOutput(ctx, "--show-pages", file)
+	if err != nil {
+		return err
+	}
+	pages := map[int]string{}
+	for _, m := range pageObject.FindAllStringSubmatch(shown, -1) {
+		n, _ := strconv.Atoi(m[1])
+		pages[n] = m[2]
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return internalError("cannot read pdf")
+	}
+	prev := startXref.FindSubmatch(data)
+	if prev == nil {
+		return internalError("cannot find pdf cross-reference table")
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(trailerSize.FindString(trailer), "/Size")))
+	if err != nil {
+		return internalError("cannot read pdf trailer")
+	}
+
+	// The outline dictionary is object size, its items the ones after it.
+	outlines := size
+	item := func(i int) string { return fmt.Sprintf("%d 0 R", outlines+1+i) }
+	objects := []string{fmt.Sprintf("<< /Type /Outlines /First %s /Last %s /Count %d >>",
+		item(0), item(len(entries)-1), len(entries))}
+	for i, e := range entries {
+		page, ok := pages[e.page]
+		if !ok {
+			return internalError(fmt.Sprintf("cannot find page %d for bookmark", e.page))
+		}
+		var obj strings.Builder
+		fmt.Fprintf(&obj, "<< /Title %s /Parent %d 0 R /Dest [%s /Fit]", pdfHexString(e.title), outlines, page)
+		if i > 0 {
+			fmt.Fprintf(&obj, " /Prev %s", item(i-1))
+		}
+		if i < len(entries)-1 {
+			fmt.Fprintf(&obj, " /Next %s", item(i+1))
+		}
+		obj.WriteString(" >>")
+		objects = append(objects, obj.String())
+	}
+	catalog = catalogOutline.ReplaceAllString(strings.TrimSuffix(catalog, ">>"), "") +
+		fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines >>", outlines)
+
+	var update bytes.Buffer
+	offset := func() int { return len(data) + update.Len() }
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		update.WriteByte('\n')
+	}
+	catalogOffset := offset()
+	fmt.Fprintf(&update, "%s %s obj\n%s\nendobj\n", root[1], root[2], catalog)
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = offset()
+		fmt.Fprintf(&update, "%d 0 obj\n%s\nendobj\n", outlines+i, obj)
+	}
+
+	xref := offset()
+	gen, _ := strconv.Atoi(root[2])
+	fmt.Fprintf(&update, "xref\n%s 1\n%010d %05d n \n%d %d\n", root[1], catalogOffset, gen, outlines, len(objects))
+	for _, o := range offsets {
+		fmt.Fprintf(&update, "%010d 00000 n \n", o)
+	}
+	trailer = trailerPrev.ReplaceAllString(strings.TrimSpace(trailer), "")
+	trailer = trailerSize.ReplaceAllString(trailer, fmt.Sprintf("/Size %d", outlines+len(objects)))
+	fmt.Fprintf(&update, "trailer\n%s /Prev %s >>\nstartxref\n%d\n%%%%EOF\n", strings.TrimSuffix(trailer, ">>"), prev[1], xref)
+
+	if err := os.WriteFile(file, append(data, update.Bytes()...), 0600); err != nil {
+		return internalError("cannot write pdf")
+	}
+	return nil
+}
+
+// qpdfOutput runs qpdf like runQPDF and returns what it prints.
+func qpdfOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "qpdf", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err == nil || (errors.As(err, &exitErr) && exitErr.ExitCode() == qpdfWarnings) {
+		return string(out), nil
+	}
+	if ctx.Err() != nil {
+		return "", toolFailed(ctx, "qpdf failed", "")
+	}
+	return "", &apiError{status: http.StatusInternalServerError, msg: "qpdf failed", detail: stderr.String()}
+}
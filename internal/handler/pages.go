@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxOutputPages caps how many pages one request may turn into images.
+const maxOutputPages = 100
+
+// selectPages expands a page selection such as "1,3-5" into ascending page
+// numbers, dropping pages past total. An empty selection is every page.
+func selectPages(spec string, total int) ([]int, error) {
+	if spec == "" {
+		spec = "1-" + strconv.Itoa(total)
+	}
+
+	seen := map[int]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, _ := strconv.Atoi(from)
+		last := first
+		if isRange {
+			last, _ = strconv.Atoi(to)
+		}
+		if last < first {
+			return nil, badRequest(fmt.Sprintf("invalid pages %q: range %s is reversed", spec, part))
+		}
+		for page := first; page <= min(last, total); page++ {
+			seen[page] = true
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, badRequest(fmt.Sprintf("no pages selected: the document has %d", total))
+	}
+	if len(seen) > maxOutputPages {
+		return nil, badRequest(fmt.Sprintf("too many pages selected: at most %d", maxOutputPages))
+	}
+
+	pages := make([]int, 0, len(seen))
+	for page := range seen {
+		pages = append(pages, page)
+	}
+	slices.Sort(pages)
+	return pages, nil
+}
+
+// pageFiles lists the job's "<id>-<page><ext>" outputs ordered by page number.
+func pageFiles(j *job, ext string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(j.dir, j.id+"-*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(files, func(a, b string) int {
+		return pageNumber(j, a, ext) - pageNumber(j, b, ext)
+	})
+	return files, nil
+}
+
+func pageNumber(j *job, file, ext string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), j.id+"-"), ext))
+	return n
+}
+
+// pagesResponse bundles the rendered pages of req. With bundle=links every
+// page is put in the artifact store and a PageLinks JSON of absolute URLs is
+// returned; otherwise a single page is returned as-is and several pages as a
+// zip of page-N<ext> files, N being the page number in the document.
+func pagesResponse(j *job, pages []string, ext, contentType string, req *RenderReq) ([]byte, string, error) {
+	if req.Options.Bundle == "links" {
+		links := PageLinks{Pages: make([]PageLink, len(pages))}
+		for i, page := range pages {
+			data, err := os.ReadFile(page)
+			if err != nil {
+				return nil, "", internalError("cannot read output")
+			}
+			n := pageNumber(j, page, ext)
+			url, err := storeArtifact(req.baseURL, fmt.Sprintf("page-%d%s", n, ext), contentType, data)
+			if err != nil {
+				return nil, "", err
+			}
+			links.Pages[i] = PageLink{Page: n, URL: url, ContentType: contentType}
+		}
+		out, err := json.Marshal(links)
+		if err != nil {
+			return nil, "", internalError("cannot encode response")
+		}
+		return out, "application/json; charset=utf-8", nil
+	}
+
+	if len(pages) == 1 {
+		out, err := os.ReadFile(pages[0])
+		if err != nil {
+			return nil, "", internalError("cannot read output")
+		}
+		return out, contentType, nil
+	}
+
+	names := make([]string, len(pages))
+	for i, page := range pages {
+		names[i] = fmt.Sprintf("page-%d%s", pageNumber(j, page, ext), ext)
+	}
+	out, err := zipFiles(names, pages)
+	if err != nil {
+		return nil, "", internalError("cannot archive output")
+	}
+	return out, "application/zip", nil
+}
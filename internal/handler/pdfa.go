@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxDiagnostics caps how many conformance failures a PDFAReport lists.
+const maxDiagnostics = 50
+
+var (
+	// texTitle and texAuthor match the argument of \title and \author,
+	// allowing one level of nested braces as in \title{A \emph{b}}.
+	texTitle  = regexp.MustCompile(`\\title\s*\{((?:[^{}]|\{[^{}]*\})*)\}`)
+	texAuthor = regexp.MustCompile(`\\author\s*\{((?:[^{}]|\{[^{}]*\})*)\}`)
+
+	pdfaidPart        = regexp.MustCompile(`pdfaid:part(?:="|>)\s*(\d)`)
+	pdfaidConformance = regexp.MustCompile(`pdfaid:conformance(?:="|>)\s*([A-Za-z])`)
+
+	// pdfStream matches the start of a stream's data.
+	pdfStream = regexp.MustCompile(`stream\r?\n`)
+)
+
+// pdfaSetup returns the preamble code that loads pdfx for a PDF/A level.
+// pdfx sets up the sRGB output intent, the XMP packet and the PDF version;
+// it also loads hyperref, so documents must configure hyperref with
+// \hypersetup rather than package options.
+func pdfaSetup(level string) string {
+	return fmt.Sprintf("\\usepackage[a-%s]{pdfx}\n", level)
+}
+
+// writeXMPData writes the job's .xmpdata file, from which pdfx fills the XMP
+// packet. Fields come from the request metadata, with the title and author
+// declared in source as fallback.
+func writeXMPData(j *job, source string, m *PDFMetadata) error {
+	if m == nil {
+		m = &PDFMetadata{}
+	}
+	title, author := texEscape(m.Title), texEscape(m.Author)
+	if match := texTitle.FindStringSubmatch(source); title == "" && match != nil {
+		title = strings.TrimSpace(match[1])
+	}
+	if match := texAuthor.FindStringSubmatch(source); author == "" && match != nil {
+		// pdfx separates authors with \sep, LaTeX with \and.
+		author = strings.ReplaceAll(strings.TrimSpace(match[1]), `\and`, `\sep`)
+	}
+
+	var buf strings.Builder
+	if title != "" {
+		fmt.Fprintf(&buf, "\\Title{%s}\n", title)
+	}
+	if author != "" {
+		fmt.Fprintf(&buf, "\\Author{%s}\n", author)
+	}
+	if m.Subject != "" {
+		fmt.Fprintf(&buf, "\\Subject{%s}\n", texEscape(m.Subject))
+	}
+	if len(m.Keywords) > 0 {
+		keywords := make([]string, len(m.Keywords))
+		for i, keyword := range m.Keywords {
+			keywords[i] = texEscape(keyword)
+		}
+		fmt.Fprintf(&buf, "\\Keywords{%s}\n", strings.Join(keywords, `\sep `))
+	}
+	if err := os.WriteFile(j.path(".xmpdata"), []byte(buf.String()), 0600); err != nil {
+		return internalError("cannot write xmp data")
+	}
+	return nil
+}
+
+// validatePDFA checks file against a PDF/A level with veraPDF when it is
+// installed, and with checkPDFA otherwise.
+func validatePDFA(ctx context.Context, file, level string) (*PDFAReport, error) {
+	if verapdf, err := exec.LookPath("verapdf"); err == nil {
+		return runVeraPDF(ctx, verapdf, file, level)
+	}
+	return checkPDFA(ctx, file, level)
+}
+
+// pdfaFailed rejects a non-compliant render, listing the failures as detail.
+func pdfaFailed(report *PDFAReport) error {
+	return &apiError{
+		status: http.StatusUnprocessableEntity,
+		msg:    "pdf/a-" + report.Level + " validation failed",
+		detail: strings.Join(report.Diagnostics, "\n"),
+	}
+}
+
+// runVeraPDF validates file with veraPDF's text report, whose first line is
+// "PASS <file>" or "FAIL <file>" followed, with --verbose, by the failed rules.
+func runVeraPDF(ctx context.Context, verapdf, file, level string) (*PDFAReport, error) {
+	cmd := exec.CommandContext(ctx, verapdf, "--flavour", level, "--format", "text", "--verbose", file)
+	// veraPDF exits non-zero for non-compliant files, so only the report is checked.
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, toolFailed(ctx, "pdf/a validation failed", "")
+	}
+
+	report := &PDFAReport{Level: level, Validator: "verapdf"}
+	verdict := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case !verdict && (strings.HasPrefix(line, "PASS ") || strings.HasPrefix(line, "FAIL ")):
+			verdict = true
+			report.Compliant = strings.HasPrefix(line, "PASS ")
+		case verdict:
+			report.addDiagnostic(line)
+		}
+	}
+	if !verdict {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, &apiError{status: http.StatusInternalServerError, msg: "pdf/a validation failed", detail: string(exitErr.Stderr)}
+		}
+		return nil, internalError("pdf/a validation failed")
+	}
+	return report, nil
+}
+
+// checkPDFA is the built-in structural check used without veraPDF. It covers
+// the requirements TeX output most often misses: the XMP identification, the
+// output intent, encryption, font embedding and, for PDF/A-1, the PDF 1.4
+// feature set. It does not replace a full validator.
+func checkPDFA(ctx context.Context, file, level string) (*PDFAReport, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, internalError("cannot read output")
+	}
+	info, err := readPDFInfo(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	unembedded, err := readUnembeddedFonts(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PDFAReport{Level: level, Validator: "builtin"}
+	part, conformance := level[:1], strings.ToUpper(level[1:])
+
+	// Object streams hide dictionaries from a plain byte search, so look in
+	// the decompressed streams as well.
+	objects := slices.Concat(data, inflateStreams(data))
+
+	if m := pdfaidPart.FindSubmatch(objects); m == nil {
+		report.addDiagnostic("xmp metadata does not declare pdfaid:part")
+	} else if string(m[1]) != part {
+		report.addDiagnostic(fmt.Sprintf("xmp metadata declares pdfaid:part %s, expected %s", m[1], part))
+	}
+	if m := pdfaidConformance.FindSubmatch(objects); m == nil {
+		report.addDiagnostic("xmp metadata does not declare pdfaid:conformance")
+	} else if strings.ToUpper(string(m[1])) != conformance {
+		report.addDiagnostic(fmt.Sprintf("xmp metadata declares pdfaid:conformance %s, expected %s", m[1], conformance))
+	}
+	if !bytes.Contains(objects, []byte("/GTS_PDFA1")) {
+		report.addDiagnostic("no GTS_PDFA1 output intent")
+	}
+	if info.Encrypted {
+		report.addDiagnostic("document is encrypted")
+	}
+	for _, font := range unembedded {
+		report.addDiagnostic("font not embedded: " + font)
+	}
+
+	maxVersion := 1.7
+	if part == "1" {
+		maxVersion = 1.4
+		if bytes.Contains(data, []byte("/ObjStm")) {
+			report.addDiagnostic("object streams are not allowed in pdf/a-1")
+		}
+		if bytes.Contains(objects, []byte("/S /Transparency")) || bytes.Contains(objects, []byte("/S/Transparency")) {
+			report.addDiagnostic("transparency groups are not allowed in pdf/a-1")
+		}
+	}
+	if version, err := strconv.ParseFloat(info.Version, 64); err == nil && version > maxVersion {
+		report.addDiagnostic(fmt.Sprintf("pdf version %s is above %.1f", info.Version, maxVersion))
+	}
+
+	report.Compliant = len(report.Diagnostics) == 0
+	return report, nil
+}
+
+func (r *PDFAReport) addDiagnostic(msg string) {
+	if len(r.Diagnostics) < maxDiagnostics {
+		r.Diagnostics = append(r.Diagnostics, msg)
+	}
+}
+
+// inflateStreams returns the concatenated data of every Flate-compressed
+// stream in data; streams that do not inflate are skipped.
+func inflateStreams(data []byte) []byte {
+	var out bytes.Buffer
+	for _, loc := range pdfStream.FindAllIndex(data, -1) {
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[start : start+end]))
+		if err != nil {
+			continue
+		}
+		// A truncated read still yields the dictionaries before the error.
+		io.Copy(&out, zr)
+		zr.Close()
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// readUnembeddedFonts lists the fonts that pdffonts reports as not embedded.
+func readUnembeddedFonts(ctx context.Context, file string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "pdffonts", file).Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, toolFailed(ctx, "pdf/a validation failed", "")
+		}
+		return nil, internalError("cannot read pdf fonts")
+	}
+	return parseUnembeddedFonts(out), nil
+}
+
+// parseUnembeddedFonts reads pdffonts output, a table whose column widths
+// are given by the dashed rule under its header:
+//
+//	name                 type      encoding  emb sub uni object ID
+//	-------------------- --------- --------- --- --- --- ---------
+//	ABCDEF+CMR10         Type 1    Builtin   yes yes no       4  0
+//
+// Names and types may contain spaces, so columns are cut by position.
+func parseUnembeddedFonts(out []byte) []string {
+	lines := strings.Split(string(out), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+
+	// Column i spans cols[i][0]:cols[i][1] of every row.
+	var cols [][2]int
+	for start := 0; start < len(lines[1]); {
+		if lines[1][start] != '-' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines[1]) && lines[1][end] == '-' {
+			end++
+		}
+		cols = append(cols, [2]int{start, end})
+		start = end
+	}
+	const nameCol, embCol = 0, 3
+	if len(cols) <= embCol {
+		return nil
+	}
+
+	column := func(row string, i int) string {
+		start, end := cols[i][0], min(cols[i][1], len(row))
+		if start >= end {
+			return ""
+		}
+		return strings.TrimSpace(row[start:end])
+	}
+
+	var fonts []string
+	for _, row := range lines[2:] {
+		if strings.TrimSpace(row) == "" {
+			continue
+		}
+		if column(row, embCol) == "no" {
+			name := column(row, nameCol)
+			if name == "" {
+				name = "[none]"
+			}
+			fonts = append(fonts, name)
+		}
+	}
+	return fonts
+}
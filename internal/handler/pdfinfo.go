@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pdfInfo is what pdfinfo reports about a PDF.
+type pdfInfo struct {
+	Pages int
+	// PageSizes holds the width and height of each page, in points.
+	PageSizes [][2]float64
+	// Version is the PDF version, e.g. "1.5".
+	Version   string
+	Encrypted bool
+	// Fields holds the Info dictionary entries, such as Title and Author.
+	Fields map[string]string
+}
+
+// infoFields are the Info dictionary entries pdfinfo prints.
+var infoFields = []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate"}
+
+// readPDFInfo runs pdfinfo over every page of file.
+func readPDFInfo(ctx context.Context, file string) (*pdfInfo, error) {
+	// pdfinfo clamps -l to the page count, so this covers every page.
+	out, err := exec.CommandContext(ctx, "pdfinfo", "-isodates", "-f", "1", "-l", strconv.Itoa(1<<30), file).Output()
+	if err != nil {
+		return nil, internalError("cannot read pdf info")
+	}
+	return parsePDFInfo(out), nil
+}
+
+// parsePDFInfo reads the "Pages:", "Page N size: W x H pts", "PDF version:",
+// "Encrypted:" and Info dictionary lines of pdfinfo output.
+func parsePDFInfo(out []byte) *pdfInfo {
+	info := &pdfInfo{Fields: map[string]string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case slices.Contains(infoFields, key):
+			info.Fields[key] = value
+		case key == "Pages":
+			info.Pages, _ = strconv.Atoi(value)
+		case key == "PDF version":
+			info.Version = value
+		case key == "Encrypted":
+			// value is "no", or "yes (print:yes copy:no ...)".
+			info.Encrypted = strings.HasPrefix(value, "yes")
+		case strings.HasPrefix(key, "Page ") && strings.HasSuffix(key, " size"):
+			// value is e.g. "612 x 792 pts (letter)".
+			fields := strings.Fields(value)
+			if len(fields) < 3 {
+				continue
+			}
+			w, errW := strconv.ParseFloat(fields[0], 64)
+			h, errH := strconv.ParseFloat(fields[2], 64)
+			if errW == nil && errH == nil {
+				info.PageSizes = append(info.PageSizes, [2]float64{w, h})
+			}
+		}
+	}
+	return info
+}
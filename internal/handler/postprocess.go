@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultWatermarkOpacity  = 0.15
+	defaultWatermarkAngle    = 45
+	defaultWatermarkFontSize = 72
+	defaultWatermarkScale    = 0.5
+	defaultStampFontSize     = 9
+	maxOverlayFontSize       = 400
+
+	// stampMargin is the distance, in points, from a stamp to the page edges.
+	stampMargin = 18
+)
+
+var (
+	watermarkColors = []string{"gray", "red", "blue", "black"}
+	stampPositions  = []string{"bottom-center", "bottom-left", "bottom-right", "top-center", "top-left", "top-right"}
+	permissions     = []string{"print", "copy", "modify", "annotate"}
+)
+
+// PostProcessing configures the steps applied to a compiled PDF, in the
+// order watermark, stamp, encrypt, linearize. Absent steps are skipped.
+type PostProcessing struct {
+	Watermark *Watermark  `json:"watermark,omitempty"`
+	Stamp     *Stamp      `json:"stamp,omitempty"`
+	Encrypt   *Encryption `json:"encrypt,omitempty"`
+	// Linearize optimises the PDF for fast web view.
+	Linearize bool `json:"linearize,omitempty" example:"false"`
+}
+
+// Watermark is drawn across the centre of every page, as text or as one of
+// the request's images.
+type Watermark struct {
+	Text string `json:"text,omitempty" example:"DRAFT"`
+	// Image is the name of an entry of the request's images.
+	Image string `json:"image,omitempty" example:"logo.png"`
+	// Opacity is between 0 (invisible) and 1 (opaque).
+	Opacity float64 `json:"opacity,omitempty" minimum:"0" maximum:"1" example:"0.15"`
+	// Angle is the counter-clockwise rotation, in degrees.
+	Angle    *float64 `json:"angle,omitempty" example:"45"`
+	FontSize int      `json:"font_size,omitempty" minimum:"6" maximum:"400" example:"72"`
+	Color    string   `json:"color,omitempty" enums:"gray,red,blue,black" example:"gray"`
+	// Scale is the image width as a fraction of the page width.
+	Scale float64 `json:"scale,omitempty" minimum:"0" maximum:"1" example:"0.5"`
+}
+
+// Stamp is a line of text at a fixed position of every page, such as a page
+// number or a confidentiality footer. {page} and {pages} in Text are replaced
+// by the page number and the page count.
+type Stamp struct {
+	Text     string `json:"text,omitempty" example:"Confidential - page {page} of {pages}"`
+	Position string `json:"position,omitempty" enums:"bottom-center,bottom-left,bottom-right,top-center,top-left,top-right" example:"bottom-center"`
+	FontSize int    `json:"font_size,omitempty" minimum:"6" maximum:"400" example:"9"`
+}
+
+// Encryption protects the PDF with AES-256. The user password is needed to
+// open it, and may be empty; the owner password lifts the restrictions.
+type Encryption struct {
+	UserPassword  string `json:"user_password,omitempty"`
+	OwnerPassword string `json:"owner_password"`
+	// Allow lists what readers may do without the owner password.
+	Allow []string `json:"allow,omitempty" enums:"print,copy,modify,annotate" example:"print"`
+}
+
+// normalize fills in defaults and validates every step against req.
+func (p *PostProcessing) normalize(req *RenderReq) error {
+	if w := p.Watermark; w != nil {
+		if (w.Text == "") == (w.Image == "") {
+			return badRequest("watermark needs either text or image")
+		}
+		if _, ok := req.Images[w.Image]; w.Image != "" && !ok {
+			return badRequest(fmt.Sprintf("watermark image %q is not in images", w.Image))
+		}
+		if w.Opacity == 0 {
+			w.Opacity = defaultWatermarkOpacity
+		}
+		if w.Angle == nil {
+			angle := float64(defaultWatermarkAngle)
+			w.Angle = &angle
+		}
+		if w.FontSize == 0 {
+			w.FontSize = defaultWatermarkFontSize
+		}
+		if w.Color == "" {
+			w.Color = "gray"
+		}
+		if w.Scale == 0 {
+			w.Scale = defaultWatermarkScale
+		}
+		if w.Opacity < 0 || w.Opacity > 1 {
+			return badRequest("invalid watermark opacity: must be between 0 and 1")
+		}
+		if w.Scale < 0 || w.Scale > 1 {
+			return badRequest("invalid watermark scale: must be between 0 and 1")
+		}
+		if w.FontSize < 6 || w.FontSize > maxOverlayFontSize {
+			return badRequest(fmt.Sprintf("invalid watermark font_size: must be between 6 and %d", maxOverlayFontSize))
+		}
+		if err := oneOf("watermark color", w.Color, watermarkColors); err != nil {
+			return err
+		}
+	}
+
+	if p.Stamp != nil {
+		if err := p.Stamp.normalize(); err != nil {
+			return err
+		}
+	}
+
+	if e := p.Encrypt; e != nil {
+		if e.OwnerPassword == "" {
+			return badRequest("encrypt needs an owner_password")
+		}
+		// qpdf reads the passwords one per line, see runQPDFSecret.
+		if strings.ContainsAny(e.OwnerPassword+e.UserPassword, "\r\n") {
+			return badRequest("encrypt passwords must not contain line breaks")
+		}
+		for _, perm := range e.Allow {
+			if err := oneOf("encrypt permission", perm, permissions); err != nil {
+				return err
+			}
+		}
+		if req.Options.PDFA != "" {
+			return badRequest("encrypt is not allowed with pdfa: PDF/A forbids encryption")
+		}
+		if req.Options.Reproducible {
+			return badRequest("encrypt is not allowed with reproducible: encryption salts are random")
+		}
+	}
+	return nil
+}
+
+func (s *Stamp) normalize() error {
+	if s.Text == "" {
+		s.Text = "{page}"
+	}
+	if s.Position == "" {
+		s.Position = "bottom-center"
+	}
+	if s.FontSize == 0 {
+		s.FontSize = defaultStampFontSize
+	}
+	if err := oneOf("stamp position", s.Position, stampPositions); err != nil {
+		return err
+	}
+	if s.FontSize < 6 || s.FontSize > maxOverlayFontSize {
+		return badRequest(fmt.Sprintf("invalid stamp font_size: must be between 6 and %d", maxOverlayFontSize))
+	}
+	return nil
+}
+
+// PDFPostProcessor is one step of the post-processing chain run over a
+// compiled PDF. Each step reads the previous step's output.
+type PDFPostProcessor interface {
+	// Name identifies the step in errors.
+	Name() string
+	// Process transforms the PDF at in and returns the path of its output,
+	// written inside the job directory.
+	Process(ctx context.Context, j *job, in string) (string, error)
+}
+
+// chain returns the configured steps in the order they run. With
+// deterministic set, qpdf derives the /ID of its output from the content
+// instead of the time, as reproducible renders need.
+func (p *PostProcessing) chain(deterministic bool) []PDFPostProcessor {
+	var steps []PDFPostProcessor
+	if p.Watermark != nil {
+		steps = append(steps, &overlayStep{name: "watermark", draw: p.Watermark.draw, deterministic: deterministic})
+	}
+	if p.Stamp != nil {
+		steps = append(steps, &overlayStep{name: "stamp", draw: p.Stamp.draw, deterministic: deterministic})
+	}
+	if p.Encrypt != nil {
+		steps = append(steps, &encryptStep{enc: p.Encrypt})
+	}
+	if p.Linearize {
+		var password string
+		if p.Encrypt != nil {
+			password = p.Encrypt.OwnerPassword
+		}
+		steps = append(steps, &linearizeStep{password: password, deterministic: deterministic})
+	}
+	return steps
+}
+
+// postProcess runs the chain over pdfFile. It returns the final PDF and the
+// last unencrypted one, from which the report extras are read since poppler
+// cannot open an encrypted file without its password.
+func postProcess(ctx context.Context, j *job, steps []PDFPostProcessor, pdfFile string) (final, readable string, err error) {
+	final, readable = pdfFile, pdfFile
+	encrypted := false
+	for _, step := range steps {
+		if final, err = step.Process(ctx, j, final); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				err = internalError(step.Name() + " failed")
+			}
+			return "", "", err
+		}
+		if _, ok := step.(*encryptStep); ok {
+			encrypted = true
+		}
+		if !encrypted {
+			readable = final
+		}
+	}
+	return final, readable, nil
+}
+
+// overlayStep draws on every page by compiling an overlay PDF with one page
+// per input page, of the same size, and laying it over the input with qpdf.
+// The input's content, text and links are kept as they are.
+type overlayStep struct {
+	name string
+	// draw returns the TikZ commands for page, of size w x h points.
+	draw          func(page, pages int, w, h float64) string
+	deterministic bool
+}
+
+func (s *overlayStep) Name() string { return s.name }
+
+const overlayTemplate = `\documentclass{article}
+\usepackage[T1]{fontenc}
+\usepackage{lmodern}
+\usepackage{graphicx}
+\usepackage{tikz}
+\pdfhorigin=0pt
+\pdfvorigin=0pt
+\begin{document}
+%s\end{document}
+`
+
+// overlayPage ships out one page of size w x h whose origin is its bottom
+// left corner.
+const overlayPage = `\pdfpagewidth=%[1]sbp\pdfpageheight=%[2]sbp
+\shipout\hbox{\begin{tikzpicture}\useasboundingbox (0,0) rectangle (%[1]sbp,%[2]sbp);%[3]s\end{tikzpicture}}
+`
+
+func (s *overlayStep) Process(ctx context.Context, j *job, in string) (string, error) {
+	info, err := readPDFInfo(ctx, in)
+	if err != nil {
+		return "", err
+	}
+
+	var pages strings.Builder
+	for i, size := range info.PageSizes {
+		w, h := size[0], size[1]
+		fmt.Fprintf(&pages, overlayPage, points(w), points(h), s.draw(i+1, info.Pages, w, h))
+	}
+
+	jobname := j.id + "-" + s.name
+	if err := os.WriteFile(filepath.Join(j.dir, jobname+".tex"), []byte(fmt.Sprintf(overlayTemplate, pages.String())), 0600); err != nil {
+		return "", internalError("cannot write " + s.name + " overlay")
+	}
+
+	cmd := exec.CommandContext(ctx, "pdflatex", "-interaction=nonstopmode", "-halt-on-error", jobname+".tex")
+	cmd.Dir = j.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", toolFailed(ctx, s.name+" failed", commandDetail(stderr.String(), filepath.Join(j.dir, jobname+".log")))
+	}
+
+	out := j.path("-" + s.name + ".pdf")
+	args := []string{in, "--overlay", filepath.Join(j.dir, jobname+".pdf"), "--", out}
+	if s.deterministic {
+		args = append(args, "--deterministic-id")
+	}
+	if err := runQPDF(ctx, args...); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func (w *Watermark) draw(_, _ int, pageW, pageH float64) string {
+	content := texEscape(w.Text)
+	font := fmt.Sprintf(`\fontsize{%d}{%d}\selectfont\bfseries`, w.FontSize, w.FontSize*6/5)
+	if w.Image != "" {
+		content = fmt.Sprintf(`\includegraphics[width=%sbp]{%s}`, points(pageW*w.Scale), filepath.Base(w.Image))
+		font = ""
+	}
+	return fmt.Sprintf(`\node[opacity=%s, text=%s, rotate=%s, font=%s] at (%sbp,%sbp) {%s};`,
+		strconv.FormatFloat(w.Opacity, 'f', 2, 64), w.Color, strconv.FormatFloat(*w.Angle, 'f', -1, 64), font,
+		points(pageW/2), points(pageH/2), content)
+}
+
+func (s *Stamp) draw(page, pages int, pageW, pageH float64) string {
+	text := strings.NewReplacer("{page}", strconv.Itoa(page), "{pages}", strconv.Itoa(pages)).Replace(s.Text)
+
+	vertical, horizontal, _ := strings.Cut(s.Position, "-")
+	y, anchor := float64(stampMargin), "south"
+	if vertical == "top" {
+		y, anchor = pageH-stampMargin, "north"
+	}
+	x := pageW / 2
+	switch horizontal {
+	case "left":
+		x, anchor = stampMargin, anchor+" west"
+	case "right":
+		x, anchor = pageW-stampMargin, anchor+" east"
+	}
+
+	return fmt.Sprintf(`\node[anchor=%s, inner sep=0pt, font=\fontsize{%d}{%d}\selectfont] at (%sbp,%sbp) {%s};`,
+		anchor, s.FontSize, s.FontSize*6/5, points(x), points(y), texEscape(text))
+}
+
+// points formats a length in points for TeX.
+func points(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// encryptStep protects the PDF with AES-256 and the configured permissions.
+type encryptStep struct {
+	enc *Encryption
+}
+
+func (s *encryptStep) Name() string { return "encrypt" }
+
+func (s *encryptStep) Process(ctx context.Context, j *job, in string) (string, error) {
+	allow := func(perm, yes, no string) string {
+		if slices.Contains(s.enc.Allow, perm) {
+			return yes
+		}
+		return no
+	}
+
+	out := j.path("-encrypt.pdf")
+	secret := []string{"--encrypt", s.enc.UserPassword, s.enc.OwnerPassword, "256"}
+	err := runQPDFSecret(ctx, secret, in, "@-",
+		allow("print", "--print=full", "--print=none"),
+		allow("copy", "--extract=y", "--extract=n"),
+		allow("modify", "--modify=all", "--modify=none"),
+		// After --modify, which also sets the annotation permission.
+		allow("annotate", "--annotate=y", "--annotate=n"),
+		"--", out,
+	)
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// linearizeStep rewrites the PDF for fast web view. An encrypted input is
+// opened with its owner password and stays encrypted.
+type linearizeStep struct {
+	password      string
+	deterministic bool
+}
+
+func (s *linearizeStep) Name() string { return "linearize" }
+
+func (s *linearizeStep) Process(ctx context.Context, j *job, in string) (string, error) {
+	out := j.path("-linearize.pdf")
+	args := []string{"--linearize"}
+	var secret []string
+	if s.password != "" {
+		secret = []string{"--password=" + s.password}
+		args = append(args, "@-")
+	}
+	if s.deterministic {
+		args = append(args, "--deterministic-id")
+	}
+	args = append(args, in, out)
+	if err := runQPDFSecret(ctx, secret, args...); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// qpdfWarnings is qpdf's exit status for a successful run with warnings.
+const qpdfWarnings = 3
+
+// runQPDF runs qpdf like runTool, accepting output written with warnings.
+func runQPDF(ctx context.Context, args ...string) error {
+	return runQPDFSecret(ctx, nil, args...)
+}
+
+// runQPDFSecret runs qpdf like runQPDF, passing secret, such as passwords,
+// through standard input in place of the "@-" argument, one argument per
+// line, so that it does not show in the process list.
+func runQPDFSecret(ctx context.Context, secret []string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "qpdf", args...)
+	if secret != nil {
+		cmd.Stdin = strings.NewReader(strings.Join(secret, "\n") + "\n")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err == nil || (errors.As(err, &exitErr) && exitErr.ExitCode() == qpdfWarnings) {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return toolFailed(ctx, "qpdf failed", "")
+	}
+	return &apiError{status: http.StatusInternalServerError, msg: "qpdf failed", detail: stderr.String()}
+}
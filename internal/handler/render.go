@@ -2,103 +2,146 @@ package handler
 
 import (
 	"bytes"
-	_ "embed"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-//go:embed static/css/LaTeXML.css
-var latexmlCSS string
-
 // Render converts LaTeX source to HTML with embedded CSS.
 //
 //	@Summary		Render LaTeX to HTML
-//	@Description	Converts a full LaTeX document into an HTML fragment with embedded LaTeXML CSS and Presentation MathML.
+//	@Description	Converts a full LaTeX document into an HTML fragment with embedded LaTeXML CSS and math as MathML or images (math_format). The stylesheet is inlined whole or with only the rules used, linked from /assets/latexml.css or left out (css), and themed (theme). Generated images are embedded as data URIs, stored as artifacts, or zipped with the page (html_images). split breaks long documents into one page per section, returned with a manifest as a zip or as artifacts (bundle). sanitize filters the output through an allow-list for embedding untrusted LaTeX. With html_mode=document the answer is a complete HTML5 page with lang, title and meta tags taken from the source and the metadata object. Alias of /v1/render/html.
 //	@Tags			render
-//	@Accept			multipart/form-data
-//	@Produce		text/html
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		text/html,application/zip,json
 //	@Param			Authorization	header		string	true	"Bearer API key"
-//	@Param			content         formData	string	true	"LaTeX source code"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
 //	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
-//	@Success		200	{string}	string	"HTML with embedded CSS"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{string}	string	"HTML with embedded CSS, or a zip of index.html and its images with html_images=zip"
 //	@Failure		400	{object}	ErrorResponse
 //	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
 //	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
 //	@Router			/render [post]
 func Render(c *gin.Context) {
-	if err := c.Request.ParseMultipartForm(20 << 20); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid form"})
-		return
-	}
-
 	req, err := newRenderReqFromContext(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
-	id := uuid.NewString()
-	tmpDir := os.TempDir()
-	texFile := filepath.Join(tmpDir, id+".tex")
-	htmlFile := filepath.Join(tmpDir, id+".html")
-
-	if err := os.WriteFile(texFile, []byte(req.Content), 0600); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "cannot write temp file"})
+	out, contentType, err := renderHTML(c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
+	c.Data(http.StatusOK, contentType, out)
+}
 
-	if err := req.downloadImages(tmpDir); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-		return
+// renderHTML converts req to HTML with latexmlc. The output is a page, or a
+// zip of the page and its images with html_images=zip; with split, it is the
+// pages and their manifest, bundled as asked.
+func renderHTML(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+	j, err := newJob(req)
+	if err != nil {
+		return nil, "", err
 	}
+	defer j.cleanup()
 
-	defer os.Remove(texFile)
-	defer os.Remove(htmlFile)
-	defer os.Remove(filepath.Join(tmpDir, id+".aux"))
-	defer os.Remove(filepath.Join(tmpDir, id+".log"))
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
 
-	cmd := exec.Command(
-		"latexmlc",
-		texFile,
-		"--dest", htmlFile,
-		"--pmml",
-		"--post",
-		"--format=html5",
-		"--whatsout=fragment",
-		"--timeout=20",
-	)
-
-	cmd.Dir = tmpDir
+	cmd := exec.CommandContext(ctx, "latexmlc", latexmlcArgs(j.path(".tex"), j.path(".html"), req.Options)...)
+	cmd.Dir = j.dir
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		log := stderr.String()
-		if log == "" {
-			if logBytes, e := os.ReadFile(filepath.Join(tmpDir, id+".log")); e == nil {
-				log = extractTexErrors(string(logBytes))
-			}
-		}
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:  "render failed",
-			Detail: log,
-		})
-		return
+		return nil, "", toolFailed(ctx, "render failed", commandDetail(stderr.String(), j.path(".log")))
 	}
 
-	html, err := os.ReadFile(htmlFile)
+	if req.Options.Split != "" {
+		return splitHTML(j, req)
+	}
+
+	html, err := os.ReadFile(j.path(".html"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "cannot read output"})
-		return
+		return nil, "", internalError("cannot read output")
+	}
+	html, images, err := finishPage(html, j, req)
+	if err != nil {
+		return nil, "", err
 	}
+	if req.Options.HTMLImages == "zip" {
+		out, err := zipOutput([]string{"index.html"}, [][]byte{html}, images)
+		return out, "application/zip", err
+	}
+	return html, "text/html; charset=utf-8", nil
+}
+
+// finishPage applies the HTML options to a page written by latexmlc. It
+// returns the images to pack next to the page with html_images=zip.
+func finishPage(html []byte, j *job, req *RenderReq) ([]byte, map[string]string, error) {
+	if req.Options.Sanitize {
+		html = sanitizeHTML(html, req.Options.StripIDs)
+	}
+	html, images, err := keepImages(html, j.dir, req.Options.HTMLImages, req.baseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Options.HTMLMode == "document" {
+		html = completeDocument(html, req)
+	}
+	return applyCSS(html, req.Options, req.baseURL), images, nil
+}
+
+// mathFormatArgs maps a math_format onto latexmlc flags. Given several
+// MathML flavours, latexmlc emits parallel markup with the first as primary.
+func mathFormatArgs(format string) []string {
+	switch format {
+	case "svg":
+		return []string{"--mathsvg"}
+	case "png":
+		return []string{"--mathimages"}
+	}
+	var args []string
+	for _, part := range strings.Split(format, "+") {
+		if part == "tex" {
+			args = append(args, "--mathtex")
+		} else {
+			args = append(args, "--"+part)
+		}
+	}
+	return args
+}
 
-	styled := fmt.Sprintf("<style>\n%s\n</style>\n%s", latexmlCSS, html)
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(styled))
+// latexmlcArgs builds the latexmlc command line for opts.
+func latexmlcArgs(texFile, htmlFile string, opts RenderOptions) []string {
+	args := []string{
+		texFile,
+		"--dest", htmlFile,
+		"--post",
+		"--format=html5",
+		"--whatsout=" + opts.HTMLMode,
+		fmt.Sprintf("--timeout=%d", opts.Timeout),
+	}
+	args = append(args, mathFormatArgs(opts.MathFormat)...)
+	if opts.Split != "" {
+		args = append(args, "--split", "--splitat="+opts.Split)
+	}
+	if opts.HTMLMode == "document" {
+		// The stylesheet is delivered by applyCSS; never link to resource
+		// files that are deleted with the job directory.
+		args = append(args, "--nodefaultresources")
+	}
+	return args
 }
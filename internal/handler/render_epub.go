@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// epubMimetype is the content of the mimetype entry that opens every EPUB.
+const epubMimetype = "application/epub+zip"
+
+// RenderEPUB converts LaTeX source to an EPUB3 book.
+//
+//	@Summary		Render LaTeX to EPUB3
+//	@Description	Converts a full LaTeX document into an EPUB3 archive with LaTeXML: one XHTML page per section, or per the unit given in split, with math as MathML or images (math_format), the document's images, the LaTeXML stylesheet and a generated navigation document. The package metadata takes the title and authors from \title and \author. Alias of /v1/render/epub.
+//	@Tags			render
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		application/epub+zip,json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{file}		binary	"EPUB3 archive"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/epub [post]
+func RenderEPUB(c *gin.Context) {
+	req, err := newRenderReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	out, contentType, err := renderEPUB(c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, out)
+}
+
+// renderEPUB converts req to an EPUB3 archive with latexmlc, which writes the
+// package document, the navigation document and the pages.
+func renderEPUB(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+	j, err := newJob(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer j.cleanup()
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	args := []string{
+		j.path(".tex"),
+		"--dest", j.path(".epub"),
+		"--post",
+		"--format=epub",
+		fmt.Sprintf("--timeout=%d", req.Options.Timeout),
+	}
+	args = append(args, mathFormatArgs(req.Options.MathFormat)...)
+	if req.Options.Split != "" {
+		args = append(args, "--splitat="+req.Options.Split)
+	}
+	cmd := exec.CommandContext(ctx, "latexmlc", args...)
+	cmd.Dir = j.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, "", toolFailed(ctx, "render failed", commandDetail(stderr.String(), j.path(".log")))
+	}
+
+	out, err := os.ReadFile(j.path(".epub"))
+	if err != nil {
+		return nil, "", internalError("cannot read output")
+	}
+	if err := checkEPUB(out); err != nil {
+		return nil, "", &apiError{status: http.StatusInternalServerError, msg: "invalid epub output", detail: err.Error()}
+	}
+	return out, epubMimetype, nil
+}
+
+// checkEPUB verifies the structure reading systems rely on: an uncompressed
+// mimetype entry first, a container pointing to the package document, and a
+// package that declares EPUB 3 with a navigation document.
+func checkEPUB(data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if len(zr.File) == 0 || zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		return errors.New("mimetype is not the first, uncompressed entry")
+	}
+	if mimetype, err := readZipEntry(zr.File[0]); err != nil || string(mimetype) != epubMimetype {
+		return fmt.Errorf("mimetype is not %s", epubMimetype)
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := readZipXML(zr, "META-INF/container.xml", &container); err != nil {
+		return err
+	}
+	if len(container.Rootfiles) == 0 {
+		return errors.New("container.xml lists no package document")
+	}
+
+	var pkg struct {
+		Version string `xml:"version,attr"`
+		Items   []struct {
+			Href       string `xml:"href,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+	}
+	opf := container.Rootfiles[0].FullPath
+	if err := readZipXML(zr, opf, &pkg); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(pkg.Version, "3") {
+		return fmt.Errorf("package version is %q, not 3", pkg.Version)
+	}
+	for _, item := range pkg.Items {
+		if !strings.Contains(" "+item.Properties+" ", " nav ") {
+			continue
+		}
+		if _, err := zr.Open(path.Join(path.Dir(opf), item.Href)); err != nil {
+			return fmt.Errorf("navigation document %s is missing", item.Href)
+		}
+		return nil
+	}
+	return errors.New("package has no navigation document")
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// readZipXML decodes the XML entry name of zr into v.
+func readZipXML(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("%s is missing", name)
+	}
+	defer f.Close()
+	if err := xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// imageFormat describes a raster output format.
+type imageFormat struct {
+	ext         string
+	contentType string
+}
+
+var imageFormats = map[string]imageFormat{
+	"png":  {ext: ".png", contentType: "image/png"},
+	"jpeg": {ext: ".jpg", contentType: "image/jpeg"},
+	"webp": {ext: ".webp", contentType: "image/webp"},
+}
+
+// RenderImage returns the handler rendering LaTeX source to page images in
+// format, one of png, jpeg or webp.
+//
+//	@Summary		Render LaTeX to PNG, JPEG or WebP
+//	@Description	Compiles a LaTeX document to PDF with the selected engine and rasterises the selected pages at the requested dpi. Pages whose pixel size would exceed max_dimension are rejected before rendering. transparent keeps the background transparent in PNG and WebP. A single page is returned as the image; several pages as a zip of page-N files, or with bundle=links as a JSON list of artifact links. Aliases of /v1/render/png, /v1/render/jpeg and /v1/render/webp.
+//	@Tags			render
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		image/png,image/jpeg,image/webp,application/zip,json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{file}		binary	"Page image, zip of page images, or PageLinks JSON"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/png [post]
+//	@Router			/render/jpeg [post]
+//	@Router			/render/webp [post]
+func RenderImage(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := newRenderReqFromContext(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		out, contentType, err := renderImage(c.Request.Context(), req, format)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, contentType, out)
+	}
+}
+
+// renderImage compiles req to PDF and rasterises the selected pages.
+func renderImage(ctx context.Context, req *RenderReq, format string) ([]byte, string, error) {
+	f := imageFormats[format]
+
+	j, err := newJob(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer j.cleanup()
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	pdfFile, err := compileTeX(ctx, j, req.Options, false, format+" render failed")
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := readPDFInfo(ctx, pdfFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pages, err := selectPages(req.Options.Pages, info.Pages)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, page := range pages {
+		if err := checkPixelSize(info, page, req.Options); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, page := range pages {
+		if err := rasterizePage(ctx, j, pdfFile, page, format, req.Options); err != nil {
+			return nil, "", err
+		}
+	}
+
+	files, err := pageFiles(j, f.ext)
+	if err != nil {
+		return nil, "", internalError("cannot read output")
+	}
+	return pagesResponse(j, files, f.ext, f.contentType, req)
+}
+
+// checkPixelSize rejects a page whose image at the requested dpi would be
+// wider or taller than max_dimension.
+func checkPixelSize(info *pdfInfo, page int, opts RenderOptions) error {
+	if page > len(info.PageSizes) {
+		return internalError("cannot read page size")
+	}
+	size := info.PageSizes[page-1]
+	width := int(math.Ceil(size[0] * float64(opts.DPI) / 72))
+	height := int(math.Ceil(size[1] * float64(opts.DPI) / 72))
+
+	if width > opts.MaxDimension || height > opts.MaxDimension {
+		return badRequest(fmt.Sprintf(
+			"page %d would be %dx%d pixels at %d dpi, over max_dimension %d",
+			page, width, height, opts.DPI, opts.MaxDimension,
+		))
+	}
+	return nil
+}
+
+// rasterizePage renders one page of pdfFile to "<id>-<page>.<ext>" with
+// pdftocairo, converting to WebP with cwebp.
+func rasterizePage(ctx context.Context, j *job, pdfFile string, page int, format string, opts RenderOptions) error {
+	cairoFormat := "-png"
+	if format == "jpeg" {
+		cairoFormat = "-jpeg"
+	}
+
+	args := []string{
+		cairoFormat,
+		"-r", strconv.Itoa(opts.DPI),
+		"-f", strconv.Itoa(page),
+		"-l", strconv.Itoa(page),
+		"-singlefile",
+	}
+	if opts.Transparent && format != "jpeg" {
+		args = append(args, "-transp")
+	}
+	prefix := j.path(fmt.Sprintf("-%d", page))
+	args = append(args, pdfFile, prefix)
+
+	if err := runTool(ctx, "pdftocairo", args...); err != nil {
+		return err
+	}
+
+	if format == "webp" {
+		if err := runTool(ctx, "cwebp", "-quiet", "-q", "90", prefix+".png", "-o", prefix+".webp"); err != nil {
+			return err
+		}
+		os.Remove(prefix + ".png")
+	}
+	return nil
+}
+
+// runTool runs a conversion tool, reporting a failure as an internal error,
+// or as a timeout when the render ran out of time.
+func runTool(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return toolFailed(ctx, name+" failed", "")
+		}
+		return &apiError{status: http.StatusInternalServerError, msg: name + " failed", detail: stderr.String()}
+	}
+	return nil
+}
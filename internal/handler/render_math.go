@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	mathTimeout = 10 * time.Second
+	maxMathSize = 64 << 10
+)
+
+var (
+	mathModes         = []string{"inline", "display"}
+	mathOutputFormats = []string{"mathml", "svg", "png"}
+)
+
+// MathReq is a single TeX math expression to render.
+type MathReq struct {
+	// TeX is the expression, without surrounding $ or \[ \].
+	TeX string `json:"tex" example:"e^{i\\pi} + 1 = 0"`
+	// Mode renders the expression as inline or display math.
+	Mode string `json:"mode,omitempty" form:"mode" enums:"inline,display" example:"inline"`
+	// Macros are definitions such as \newcommand made available to the expression.
+	Macros string `json:"macros,omitempty" form:"macros" example:"\\newcommand{\\R}{\\mathbb{R}}"`
+	// Format is the output: MathML markup, an SVG image or a PNG image.
+	Format string `json:"format,omitempty" form:"format" enums:"mathml,svg,png" example:"mathml"`
+	// DPI is the resolution of PNG output.
+	DPI int `json:"dpi,omitempty" form:"dpi" minimum:"72" maximum:"600" example:"150"`
+}
+
+// newMathReqFromContext reads a MathReq from a JSON body, or from a raw
+// text/plain body holding the expression with the other fields as query
+// parameters.
+func newMathReqFromContext(c *gin.Context) (*MathReq, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxMathSize)
+
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	req := &MathReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		return nil, badRequest("invalid query parameters")
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, readBodyError(err)
+	}
+
+	switch mediaType {
+	case "text/plain", "application/x-tex":
+		req.TeX = string(body)
+	case "application/json":
+		if err := decodeStrict(body, req); err != nil {
+			return nil, badRequest("invalid json body: " + err.Error())
+		}
+	default:
+		return nil, &apiError{
+			status: http.StatusUnsupportedMediaType,
+			msg:    "unsupported content type: use text/plain, application/x-tex or application/json",
+		}
+	}
+
+	if err := req.normalize(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (req *MathReq) normalize() error {
+	if strings.TrimSpace(req.TeX) == "" {
+		return badRequest("tex is required")
+	}
+	if req.Mode == "" {
+		req.Mode = "inline"
+	}
+	if req.Format == "" {
+		req.Format = "mathml"
+	}
+	if req.DPI == 0 {
+		req.DPI = defaultDPI
+	}
+
+	if err := oneOf("mode", req.Mode, mathModes); err != nil {
+		return err
+	}
+	if err := oneOf("format", req.Format, mathOutputFormats); err != nil {
+		return err
+	}
+	if req.DPI < minDPI || req.DPI > maxDPI {
+		return badRequest(fmt.Sprintf("invalid dpi: must be between %d and %d", minDPI, maxDPI))
+	}
+	return nil
+}
+
+// typeset returns the expression as it is typeset in math mode.
+func (req *MathReq) typeset() string {
+	if req.Mode == "display" {
+		return `\displaystyle ` + req.TeX
+	}
+	return req.TeX
+}
+
+// expression returns the typeset expression with the macros defined ahead
+// of it, for LaTeXML's math-only input mode.
+func (req *MathReq) expression() string {
+	return req.Macros + "\n" + req.typeset()
+}
+
+// RenderMath renders a single TeX math expression.
+//
+//	@Summary		Render a TeX math expression
+//	@Description	Renders one formula to MathML, SVG or PNG, without wrapping it in a document. MathML is produced by a pool of persistent LaTeXML processes so per-snippet latency stays low. A text/plain body is the expression itself, with the other fields as query parameters.
+//	@Tags			render
+//	@Accept			json,plain,application/x-tex
+//	@Produce		application/mathml+xml,image/svg+xml,image/png
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			request			body		MathReq	true	"Expression and output settings"
+//	@Success		200	{file}		binary	"MathML, SVG or PNG"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/math [post]
+func RenderMath(c *gin.Context) {
+	req, err := newMathReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	out, contentType, err := renderMath(c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, out)
+}
+
+// renderMath renders req, returning the output and its content type.
+func renderMath(ctx context.Context, req *MathReq) ([]byte, string, error) {
+	if req.Format != "mathml" {
+		ctx, cancel := context.WithTimeout(ctx, mathTimeout)
+		defer cancel()
+		return renderMathImage(ctx, req)
+	}
+
+	mathml, err := convertMathML(ctx, req.expression())
+	if err != nil {
+		return nil, "", err
+	}
+	if req.Mode == "display" {
+		mathml = displayMathML(mathml)
+	}
+	return []byte(mathml), "application/mathml+xml; charset=utf-8", nil
+}
+
+// displayMathML marks the root <math> element as display math.
+func displayMathML(mathml string) string {
+	if strings.Contains(mathml, `display="inline"`) {
+		return strings.Replace(mathml, `display="inline"`, `display="block"`, 1)
+	}
+	return strings.Replace(mathml, "<math", `<math display="block"`, 1)
+}
+
+const mathSnippetTemplate = `\documentclass[preview,border=1pt]{standalone}
+\usepackage{amsmath,amssymb}
+%s
+\begin{document}
+$%s$
+\end{document}
+`
+
+// renderMathImage typesets the expression with latex and converts the DVI
+// to SVG with dvisvgm or to PNG with dvipng.
+func renderMathImage(ctx context.Context, req *MathReq) ([]byte, string, error) {
+	id := uuid.NewString()
+	jobDir, err := newJobDir(id)
+	if err != nil {
+		return nil, "", internalError("cannot create job directory")
+	}
+	defer os.RemoveAll(jobDir)
+
+	source := fmt.Sprintf(mathSnippetTemplate, req.Macros, req.typeset())
+	if err := os.WriteFile(filepath.Join(jobDir, id+".tex"), []byte(source), 0600); err != nil {
+		return nil, "", internalError("cannot write tex file")
+	}
+
+	latex := exec.CommandContext(ctx, "latex", "-interaction=nonstopmode", "-halt-on-error", id+".tex")
+	latex.Dir = jobDir
+	var stderr bytes.Buffer
+	latex.Stderr = &stderr
+	if err := latex.Run(); err != nil {
+		return nil, "", toolFailed(ctx, "math render failed", commandDetail(stderr.String(), filepath.Join(jobDir, id+".log")))
+	}
+
+	var convert *exec.Cmd
+	var output, contentType string
+	switch req.Format {
+	case "svg":
+		output, contentType = id+".svg", "image/svg+xml"
+		convert = exec.CommandContext(ctx, "dvisvgm", "--no-fonts", "--exact-bbox", "--output="+output, id+".dvi")
+	case "png":
+		output, contentType = id+".png", "image/png"
+		convert = exec.CommandContext(ctx, "dvipng", "-D", fmt.Sprint(req.DPI), "-T", "tight", "-bg", "Transparent", "-o", output, id+".dvi")
+	}
+	convert.Dir = jobDir
+	if err := convert.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, "", toolFailed(ctx, "image conversion failed", "")
+		}
+		return nil, "", internalError("image conversion failed")
+	}
+
+	image, err := os.ReadFile(filepath.Join(jobDir, output))
+	if err != nil {
+		return nil, "", internalError("cannot read output")
+	}
+	return image, contentType, nil
+}
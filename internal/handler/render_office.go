@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// officeFormats maps the word-processor formats pandoc writes to their
+// content types.
+var officeFormats = map[string]string{
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"odt":  "application/vnd.oasis.opendocument.text",
+}
+
+// bibDatabase matches the commands that cite from a BibTeX database, which
+// pandoc formats with --citeproc. Documents with a thebibliography
+// environment keep their own list.
+var bibDatabase = regexp.MustCompile(`\\(?:bibliography|addbibresource)\s*\{`)
+
+// RenderOffice returns the handler converting LaTeX source to a word
+// processor document in format, one of docx or odt.
+//
+//	@Summary		Render LaTeX to DOCX or ODT
+//	@Description	Converts a LaTeX document to a Word or OpenDocument file with pandoc. Equations become native OMML in DOCX and MathML formulas in ODT; figures, tables, footnotes, cross-references and the title block are kept, and citations are formatted from a .bib file passed in images. Constructs pandoc could not convert are skipped; with response=report the answer is an OfficeReport JSON holding the document and a warning for each of them. Needs pandoc on the server. Aliases of /v1/render/docx and /v1/render/odt.
+//	@Tags			render
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		application/vnd.openxmlformats-officedocument.wordprocessingml.document,application/vnd.oasis.opendocument.text,json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{file}		binary	"DOCX or ODT document, or OfficeReport JSON with response=report"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		501	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/docx [post]
+//	@Router			/render/odt [post]
+func RenderOffice(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := newRenderReqFromContext(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		out, contentType, err := renderOffice(c.Request.Context(), req, format)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, contentType, out)
+	}
+}
+
+func officeRenderer(format string) documentRenderer {
+	return func(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+		return renderOffice(ctx, req, format)
+	}
+}
+
+// renderOffice converts req to format with pandoc, answering as the response
+// option asks.
+func renderOffice(ctx context.Context, req *RenderReq, format string) ([]byte, string, error) {
+	pandoc, err := exec.LookPath("pandoc")
+	if err != nil {
+		return nil, "", &apiError{status: http.StatusNotImplemented, msg: format + " export requires pandoc"}
+	}
+
+	j, err := newJob(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer j.cleanup()
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	outFile := j.path("." + format)
+	args := []string{j.path(".tex"), "--from=latex", "--to=" + format, "--output=" + outFile,
+		"--resource-path=" + j.dir, "--verbose"}
+	if bibDatabase.MatchString(req.Content) {
+		args = append(args, "--citeproc")
+	}
+	cmd := exec.CommandContext(ctx, pandoc, args...)
+	cmd.Dir = j.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, "", toolFailed(ctx, format+" render failed", stderr.String())
+	}
+
+	out, err := os.ReadFile(outFile)
+	if err != nil {
+		return nil, "", internalError("cannot read output")
+	}
+	if req.Options.Response != "report" {
+		return out, officeFormats[format], nil
+	}
+
+	report, err := json.Marshal(OfficeReport{
+		Format:      format,
+		ContentType: officeFormats[format],
+		Document:    base64.StdEncoding.EncodeToString(out),
+		Warnings:    pandocWarnings(stderr.String()),
+	})
+	if err != nil {
+		return nil, "", internalError("cannot encode response")
+	}
+	return report, "application/json; charset=utf-8", nil
+}
+
+// pandocWarnings picks from pandoc's verbose log the messages about content
+// that was lost or changed: its warnings and the LaTeX it skipped.
+func pandocWarnings(log string) []string {
+	warnings := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(log))
+	for scanner.Scan() && len(warnings) < maxDiagnostics {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "[WARNING]"):
+			warnings = append(warnings, strings.TrimSpace(strings.TrimPrefix(line, "[WARNING]")))
+		case strings.HasPrefix(line, "[INFO] Skipped"):
+			warnings = append(warnings, strings.TrimSpace(strings.TrimPrefix(line, "[INFO]")))
+		}
+	}
+	return warnings
+}
@@ -2,98 +2,241 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // RenderPDF converts LaTeX source to a PDF document.
 //
 //	@Summary		Render LaTeX to PDF
-//	@Description	Compiles a full LaTeX document into a PDF using pdflatex.
+//	@Description	Compiles a full LaTeX document into a PDF using pdflatex, or the engine selected in options. A metadata object sets the Info dictionary and XMP fields. A post_process object runs watermark, page stamp, encryption and linearisation steps over the compiled PDF. With response=report the answer is a PDFReport JSON holding the PDF, its metadata, page count and page sizes as read back from the file and, with thumbnail=true, a first-page preview from the same compilation. With pdfa=1b or 2b the document is made PDF/A and validated, with veraPDF when installed; a non-compliant result fails with 422 listing the failures, or is returned with them under pdfa in a report. Alias of /v1/render/pdf.
 //	@Tags			render
-//	@Accept			multipart/form-data
-//	@Produce		application/pdf
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		application/pdf,json
 //	@Param			Authorization	header		string	true	"Bearer API key"
-//	@Param			content         formData	string	true	"LaTeX source code"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
 //	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
-//	@Success		200	{file}		binary	"PDF document"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Param			metadata        formData	string	false	"JSON-encoded PDFMetadata: title, author, subject, keywords and custom XMP fields"
+//	@Param			post_process    formData	string	false	"JSON-encoded PostProcessing: watermark, stamp, encrypt and linearize steps"
+//	@Success		200	{file}		binary	"PDF document, or PDFReport JSON with response=report"
 //	@Failure		400	{object}	ErrorResponse
 //	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		422	{object}	ErrorResponse
 //	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
 //	@Router			/render/pdf [post]
 func RenderPDF(c *gin.Context) {
-	if err := c.Request.ParseMultipartForm(20 << 20); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid form"})
+	req, err := newRenderReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
 
-	req, err := newRenderReqFromContext(c)
+	out, contentType, err := renderPDFResponse(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		abortWithError(c, err)
 		return
 	}
+	c.Data(http.StatusOK, contentType, out)
+}
 
-	id := uuid.NewString()
-	tmpDir := os.TempDir()
+// pdfResult is everything a PDF render produced.
+type pdfResult struct {
+	PDF []byte
+	// Thumbnail is the first-page PNG preview, when requested.
+	Thumbnail []byte
+	// PDFA is the conformance check of a pdfa render.
+	PDFA *PDFAReport
+	// Info is what pdfinfo reports about the PDF, read for reports.
+	Info *pdfInfo
+}
 
-	texFile := filepath.Join(tmpDir, id+".tex")
-	pdfFile := filepath.Join(tmpDir, id+".pdf")
+// renderPDFResponse renders req and encodes the result as the response
+// option asks: the PDF itself, or a PDFReport.
+func renderPDFResponse(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+	res, err := renderPDF(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	if req.Options.Response != "report" {
+		// A non-compliant file is never returned as if it were PDF/A; the
+		// report mode returns it together with the diagnostics.
+		if res.PDFA != nil && !res.PDFA.Compliant {
+			return nil, "", pdfaFailed(res.PDFA)
+		}
+		return res.PDF, "application/pdf", nil
+	}
 
-	if err := os.WriteFile(texFile, []byte(req.Content), 0600); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "cannot write tex file"})
-		return
+	report := PDFReport{
+		PDF:      base64.StdEncoding.EncodeToString(res.PDF),
+		Metadata: documentInfo(res.Info, res.PDF),
+		Pages:    res.Info.Pages,
+		PDFA:     res.PDFA,
+	}
+	for _, size := range res.Info.PageSizes {
+		report.PageSizes = append(report.PageSizes, PageSize{Width: size[0], Height: size[1]})
+	}
+	if res.Thumbnail != nil {
+		report.Thumbnail = &Thumbnail{
+			ContentType: "image/png",
+			Data:        base64.StdEncoding.EncodeToString(res.Thumbnail),
+		}
+	}
+	out, err := json.Marshal(report)
+	if err != nil {
+		return nil, "", internalError("cannot encode response")
 	}
+	return out, "application/json; charset=utf-8", nil
+}
 
-	if err := req.downloadImages(tmpDir); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-		return
+// renderPDF compiles req to PDF with the selected engine and derives the
+// requested extras from that single compilation.
+func renderPDF(ctx context.Context, req *RenderReq) (*pdfResult, error) {
+	src, err := pdfSource(req)
+	if err != nil {
+		return nil, err
 	}
 
-	defer os.Remove(texFile)
-	defer os.Remove(pdfFile)
-	defer os.Remove(filepath.Join(tmpDir, id+".aux"))
-	defer os.Remove(filepath.Join(tmpDir, id+".log"))
+	j, err := newJob(src)
+	if err != nil {
+		return nil, err
+	}
+	defer j.cleanup()
 
-	cmd := exec.Command(
-		"pdflatex",
-		"-interaction=nonstopmode",
-		"-output-directory", tmpDir,
-		"-jobname", id,
-		texFile,
-	)
-
-	cmd.Dir = tmpDir
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		log := stderr.String()
-		if log == "" {
-			if logBytes, e := os.ReadFile(filepath.Join(tmpDir, id+".log")); e == nil {
-				log = extractTexErrors(string(logBytes))
-			}
+	if req.Options.PDFA != "" {
+		if err := writeXMPData(j, req.Content, req.Metadata); err != nil {
+			return nil, err
+		}
+	} else if req.Metadata != nil {
+		if err := writeXMP(j, req.Metadata); err != nil {
+			return nil, err
 		}
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:  "pdf render failed",
-			Detail: log,
-		})
-		return
 	}
 
-	pdf, err := os.ReadFile(pdfFile)
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	pdfFile, err := compileTeX(ctx, j, req.Options, false, "pdf render failed")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "cannot read output"})
-		return
+		return nil, err
+	}
+
+	// The extras below are read from readable, which is the output unless it
+	// was encrypted.
+	readable := pdfFile
+	if req.PostProcess != nil {
+		steps := req.PostProcess.chain(req.Options.Reproducible)
+		if pdfFile, readable, err = postProcess(ctx, j, steps, pdfFile); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &pdfResult{}
+	if res.PDF, err = os.ReadFile(pdfFile); err != nil {
+		return nil, internalError("cannot read output")
 	}
 
-	c.Data(http.StatusOK, "application/pdf", pdf)
+	if req.Options.PDFA != "" {
+		if res.PDFA, err = validatePDFA(ctx, readable, req.Options.PDFA); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Options.Thumbnail {
+		if res.Thumbnail, err = renderThumbnail(ctx, j, readable, req.Options.ThumbnailSize); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Options.Response == "report" {
+		if res.Info, err = readPDFInfo(ctx, readable); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// documentClass matches the \documentclass declaration that option setup is
+// inserted after.
+var documentClass = regexp.MustCompile(`(?m)^[ \t]*\\documentclass\s*(\[[^\]]*\])?\s*\{[^}]*\}`)
+
+// pdfSource returns req, or a copy of it whose source carries the preamble
+// setup that the pdfa, reproducible and metadata settings need.
+func pdfSource(req *RenderReq) (*RenderReq, error) {
+	var setup string
+	if req.Options.PDFA != "" {
+		setup += pdfaSetup(req.Options.PDFA)
+	}
+	if req.Metadata != nil {
+		// pdfx writes the XMP packet of PDF/A output itself.
+		setup += metadataSetup(req.Metadata, req.Options.Engine, req.Options.PDFA == "")
+	}
+
+	src := req
+	if setup != "" {
+		loc := documentClass.FindStringIndex(req.Content)
+		if loc == nil {
+			return nil, badRequest(`pdfa and metadata require a document with a \documentclass declaration`)
+		}
+		withSetup := *req
+		withSetup.Content = req.Content[:loc[1]] + "\n" + setup + req.Content[loc[1]:]
+		src = &withSetup
+	}
+
+	if req.Options.Reproducible {
+		src = reproducibleRequest(src)
+	}
+	return src, nil
+}
+
+// compileTeX runs the selected engine over the job's source as many times as
+// the requested passes and returns the path of the output. With dvi set the
+// engine produces DVI (XDV for xelatex) instead of PDF. A failed run is
+// reported with the failure message and the TeX errors as detail.
+func compileTeX(ctx context.Context, j *job, opts RenderOptions, dvi bool, failure string) (string, error) {
+	args := []string{
+		"-interaction=nonstopmode",
+		"-output-directory", j.dir,
+		"-jobname", j.id,
+	}
+	output := j.path(".pdf")
+	if dvi {
+		if opts.Engine == "xelatex" {
+			args = append(args, "-no-pdf")
+			output = j.path(".xdv")
+		} else {
+			args = append(args, "-output-format=dvi")
+			output = j.path(".dvi")
+		}
+	}
+	args = append(args, j.path(".tex"))
+
+	for pass := 0; pass < opts.Passes; pass++ {
+		cmd := exec.CommandContext(ctx, opts.Engine, args...)
+		cmd.Dir = j.dir
+		if opts.Reproducible {
+			cmd.Env = append(os.Environ(), reproducibleEnv(opts)...)
+		}
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return "", toolFailed(ctx, failure, commandDetail(stderr.String(), j.path(".log")))
+		}
+	}
+	return output, nil
 }
 
 // extractTexErrors extracts LaTeX error lines starting with "!" from the compilation log.
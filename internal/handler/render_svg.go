@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// standaloneClass matches a \documentclass line selecting the standalone class.
+var standaloneClass = regexp.MustCompile(`\\documentclass\s*(\[[^\]]*\])?\s*\{standalone\}`)
+
+// RenderSVG converts LaTeX source to SVG, one image per page.
+//
+//	@Summary		Render LaTeX to SVG
+//	@Description	Compiles a LaTeX document with the selected engine and converts each page to SVG with dvisvgm, through the engine's DVI output or its PDF (svg_route). Standalone-class documents are cropped to their content. At most 100 pages are converted; select them with pages. A single page is returned as image/svg+xml, several pages as a zip of page-N.svg files, or with bundle=links as a JSON list of artifact links. Alias of /v1/render/svg.
+//	@Tags			render
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		image/svg+xml,application/zip,json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{file}		binary	"SVG image, zip of SVG pages, or PageLinks JSON"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/svg [post]
+func RenderSVG(c *gin.Context) {
+	req, err := newRenderReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	out, contentType, err := renderSVG(c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, out)
+}
+
+// renderSVG compiles req and converts the selected pages to SVG.
+func renderSVG(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+	j, err := newJob(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer j.cleanup()
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	input, err := compileTeX(ctx, j, req.Options, req.Options.SVGRoute == "dvi", "svg render failed")
+	if err != nil {
+		return nil, "", err
+	}
+
+	total, err := pageCount(ctx, input, req.Options.SVGRoute)
+	if err != nil {
+		return nil, "", err
+	}
+	selected, err := selectPages(req.Options.Pages, total)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cmd := exec.CommandContext(ctx, "dvisvgm", dvisvgmArgs(j, input, selected, req)...)
+	cmd.Dir = j.dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, "", toolFailed(ctx, "svg conversion failed", stderr.String())
+	}
+
+	pages, err := pageFiles(j, ".svg")
+	if err != nil || len(pages) == 0 {
+		return nil, "", badRequest("no pages selected")
+	}
+	return pagesResponse(j, pages, ".svg", "image/svg+xml", req)
+}
+
+// pageCount returns how many pages the compiled input has, from the DVI
+// postamble or from pdfinfo.
+func pageCount(ctx context.Context, input, route string) (int, error) {
+	if route == "pdf" {
+		info, err := readPDFInfo(ctx, input)
+		if err != nil {
+			return 0, err
+		}
+		return info.Pages, nil
+	}
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return 0, internalError("cannot read dvi output")
+	}
+	pages, ok := dviPageCount(data)
+	if !ok {
+		return 0, internalError("cannot read page count of dvi output")
+	}
+	return pages, nil
+}
+
+// dviPageCount reads the total page count from the postamble of a DVI or
+// XDV file. The file ends with post_post, a pointer to the postamble, the
+// format id and four to seven 223 bytes; the count is the postamble's last
+// two-byte field.
+func dviPageCount(data []byte) (int, bool) {
+	const (
+		opPost     = 248
+		opPostPost = 249
+		filler     = 223
+	)
+	end := len(data)
+	for end > 0 && data[end-1] == filler {
+		end--
+	}
+	// post_post, q[4], id[1]
+	if end < 6 || data[end-6] != opPostPost {
+		return 0, false
+	}
+	post := int(binary.BigEndian.Uint32(data[end-5 : end-1]))
+	// post, p[4], num[4], den[4], mag[4], l[4], u[4], s[2], t[2]
+	if post < 0 || post+29 > len(data) || data[post] != opPost {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint16(data[post+27 : post+29])), true
+}
+
+func dvisvgmArgs(j *job, input string, pages []int, req *RenderReq) []string {
+	opts := req.Options
+	list := make([]string, len(pages))
+	for i, page := range pages {
+		list[i] = strconv.Itoa(page)
+	}
+
+	args := []string{
+		"--page=" + strings.Join(list, ","),
+		"--output=" + j.id + "-%p.svg",
+	}
+	if opts.SVGRoute == "pdf" {
+		args = append(args, "--pdf")
+	}
+	if opts.SVGFonts == "paths" {
+		args = append(args, "--no-fonts")
+	} else {
+		args = append(args, "--font-format=woff")
+	}
+	if cropToContent(req) {
+		args = append(args, "--bbox=min", "--exact-bbox")
+	} else {
+		args = append(args, "--bbox=papersize")
+	}
+	return append(args, input)
+}
+
+// cropToContent reports whether image output is trimmed to its content:
+// always for crop=content, and for standalone-class documents under auto.
+func cropToContent(req *RenderReq) bool {
+	switch req.Options.Crop {
+	case "content":
+		return true
+	case "page":
+		return false
+	}
+	return standaloneClass.MatchString(req.Content)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenderThumbnail converts LaTeX source to a preview of its first page.
+//
+//	@Summary		Render a first-page thumbnail
+//	@Description	Compiles a LaTeX document to PDF and returns only a PNG preview of its first page, thumbnail_size pixels on its longer side. To get the PDF and its thumbnail from one compilation, use /render/pdf with response=report and thumbnail=true. Alias of /v1/render/thumbnail.
+//	@Tags			render
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		image/png
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{file}		binary	"PNG thumbnail"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/thumbnail [post]
+func RenderThumbnail(c *gin.Context) {
+	req, err := newRenderReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	thumbnail, err := renderThumbnailOnly(c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "image/png", thumbnail)
+}
+
+func renderThumbnailOnly(ctx context.Context, req *RenderReq) ([]byte, error) {
+	req.Options.Thumbnail = true
+	res, err := renderPDF(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Thumbnail, nil
+}
+
+// renderThumbnail renders the first page of pdfFile to a PNG whose longer
+// side is size pixels.
+func renderThumbnail(ctx context.Context, j *job, pdfFile string, size int) ([]byte, error) {
+	prefix := j.path("-thumbnail")
+	err := runTool(ctx, "pdftocairo",
+		"-png",
+		"-f", "1",
+		"-l", "1",
+		"-singlefile",
+		"-scale-to", strconv.Itoa(size),
+		pdfFile, prefix,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail, err := os.ReadFile(prefix + ".png")
+	if err != nil {
+		return nil, internalError("cannot read thumbnail")
+	}
+	return thumbnail, nil
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static/xslt/*.xsl
+var xsltFiles embed.FS
+
+// xmlFormat is an XML vocabulary produced from LaTeXML's XML output by one of
+// the bundled stylesheets.
+type xmlFormat struct {
+	stylesheet  string
+	contentType string
+	// schema is where the Docker image installs the DTD or RelaxNG schema
+	// the output is validated against.
+	schema  string
+	relaxNG bool
+}
+
+var xmlFormats = map[string]xmlFormat{
+	"jats": {
+		stylesheet:  "latexml-jats.xsl",
+		contentType: "application/jats+xml; charset=utf-8",
+		schema:      "/usr/share/xml/jats/JATS-archivearticle1-3-mathml3.dtd",
+	},
+	"tei": {
+		stylesheet:  "latexml-tei.xsl",
+		contentType: "application/tei+xml; charset=utf-8",
+		schema:      "/usr/share/xml/tei/tei_all.rng",
+		relaxNG:     true,
+	},
+}
+
+// RenderXML returns the handler rendering LaTeX source to XML in format, one
+// of jats or tei.
+//
+//	@Summary		Render LaTeX to JATS or TEI XML
+//	@Description	Converts a full LaTeX document to LaTeXML's XML with presentation MathML and transforms it with a bundled XSLT stylesheet into a JATS 1.3 Archiving article or a TEI P5 document. The result is validated against the JATS DTD or the TEI RelaxNG schema vendored with the service; invalid output fails with 422 listing the validity errors, and fails with 500 if the schema is missing, or with response=report is returned as an XMLReport JSON saying whether and how it validated. Graphics keep the file names used in the source. Aliases of /v1/render/jats and /v1/render/tei.
+//	@Tags			render
+//	@Accept			plain,application/x-tex,json,mpfd
+//	@Produce		application/jats+xml,application/tei+xml,json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Param			content         formData	string	true	"LaTeX source code. For text/plain or application/x-tex the raw body is the source; for JSON send {content, images, options}"
+//	@Param			images          formData	string	false	"JSON map of images. Example: {\"image.jpg\":{\"url\":\"https://example.com/image.jpg\"}}"
+//	@Param			options         formData	string	false	"JSON-encoded RenderOptions, as documented on /v1/render/{format}"
+//	@Success		200	{object}	XMLReport	"JATS or TEI document, or XMLReport JSON with response=report"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		422	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/render/jats [post]
+//	@Router			/render/tei [post]
+func RenderXML(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, err := newRenderReqFromContext(c)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		out, contentType, err := renderXML(c.Request.Context(), req, format)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, contentType, out)
+	}
+}
+
+func xmlRenderer(format string) documentRenderer {
+	return func(ctx context.Context, req *RenderReq) ([]byte, string, error) {
+		return renderXML(ctx, req, format)
+	}
+}
+
+// renderXML converts req to LaTeXML XML, transforms it into format and
+// validates the result, answering as the response option asks.
+func renderXML(ctx context.Context, req *RenderReq, format string) ([]byte, string, error) {
+	f := xmlFormats[format]
+
+	j, err := newJob(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer j.cleanup()
+
+	ctx, cancel := renderContext(ctx, req)
+	defer cancel()
+
+	if err := latexmlXML(ctx, j, req, "--pmml"); err != nil {
+		return nil, "", err
+	}
+
+	stylesheet, err := xsltFiles.ReadFile("static/xslt/" + f.stylesheet)
+	if err != nil {
+		return nil, "", internalError("cannot read stylesheet")
+	}
+	xslFile := filepath.Join(j.dir, f.stylesheet)
+	if err := os.WriteFile(xslFile, stylesheet, 0600); err != nil {
+		return nil, "", internalError("cannot write stylesheet")
+	}
+	outFile := j.path("." + format + ".xml")
+	cmd := exec.CommandContext(ctx, "xsltproc", "--nonet", "-o", outFile, xslFile, j.path(".xml"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, "", toolFailed(ctx, format+" transform failed", stderr.String())
+	}
+
+	out, err := os.ReadFile(outFile)
+	if err != nil {
+		return nil, "", internalError("cannot read output")
+	}
+	validation, err := validateXML(ctx, outFile, f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if req.Options.Response != "report" {
+		// Only a report may carry XML that was never validated, and says so.
+		if !validation.Validated {
+			return nil, "", &apiError{
+				status: http.StatusInternalServerError,
+				msg:    format + " schema not installed",
+				detail: validation.Schema + " is missing, so the output cannot be validated; response=report returns it unvalidated",
+			}
+		}
+		if !validation.Valid {
+			return nil, "", &apiError{
+				status: http.StatusUnprocessableEntity,
+				msg:    format + " validation failed",
+				detail: strings.Join(validation.Diagnostics, "\n"),
+			}
+		}
+		return out, f.contentType, nil
+	}
+	report, err := json.Marshal(XMLReport{Format: format, XML: string(out), Validation: *validation})
+	if err != nil {
+		return nil, "", internalError("cannot encode response")
+	}
+	return report, "application/json; charset=utf-8", nil
+}
+
+// latexmlXML converts the job's source to LaTeXML's post-processed XML, at
+// the job's .xml path. args add math formats and other latexmlc options.
+func latexmlXML(ctx context.Context, j *job, req *RenderReq, args ...string) error {
+	args = append([]string{j.path(".tex"),
+		"--dest", j.path(".xml"),
+		"--post",
+		"--format=xml",
+		fmt.Sprintf("--timeout=%d", req.Options.Timeout),
+	}, args...)
+	cmd := exec.CommandContext(ctx, "latexmlc", args...)
+	cmd.Dir = j.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return toolFailed(ctx, "render failed", commandDetail(stderr.String(), j.path(".log")))
+	}
+	return nil
+}
+
+// validateXML checks file against the schema of f with xmllint. A schema that
+// is not installed leaves the document unvalidated, which the caller
+// decides how to answer.
+func validateXML(ctx context.Context, file string, f xmlFormat) (*XMLValidation, error) {
+	validation := &XMLValidation{Schema: filepath.Base(f.schema)}
+	if _, err := os.Stat(f.schema); err != nil {
+		return validation, nil
+	}
+
+	// DTD validation goes through the DOCTYPE the stylesheet writes, whose
+	// system identifier names the DTD file; xmllint reports the errors
+	// line by line that way, unlike with --dtdvalid.
+	args := []string{"--noout", "--nonet", "--valid", "--path", filepath.Dir(f.schema)}
+	if f.relaxNG {
+		args = []string{"--noout", "--nonet", "--relaxng", f.schema}
+	}
+	cmd := exec.CommandContext(ctx, "xmllint", append(args, filepath.Base(file))...)
+	cmd.Dir = filepath.Dir(file)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	// xmllint exits with 3 or 4 for validity errors, depending on the
+	// version; any other failure is the tool's.
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && (exitErr.ExitCode() == 3 || exitErr.ExitCode() == 4)) {
+		if ctx.Err() != nil {
+			return nil, toolFailed(ctx, "xml validation failed", "")
+		}
+		return nil, &apiError{status: http.StatusInternalServerError, msg: "xml validation failed", detail: stderr.String()}
+	}
+	validation.Validated = true
+	validation.Valid = err == nil
+
+	prefix := filepath.Base(file) + ":"
+	scanner := bufio.NewScanner(&stderr)
+	for scanner.Scan() && len(validation.Diagnostics) < maxDiagnostics {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, prefix) || strings.HasSuffix(line, "fails to validate") {
+			continue
+		}
+		validation.Diagnostics = append(validation.Diagnostics, "line "+strings.TrimPrefix(line, prefix))
+	}
+	return validation, nil
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// reproducibleRequest returns a copy of req whose source fixes the PDF
+// trailer /ID to a hash of the source. Engines otherwise derive the /ID from
+// the time and the job name, which is unique per render.
+func reproducibleRequest(req *RenderReq) *RenderReq {
+	sum := sha256.Sum256([]byte(req.Content))
+	id := hex.EncodeToString(sum[:16])
+
+	var setup string
+	switch req.Options.Engine {
+	case "pdflatex":
+		setup = fmt.Sprintf(`\pdftrailerid{%s}`, id)
+	case "lualatex":
+		setup = fmt.Sprintf(`\pdfvariable trailerid {[<%s> <%s>]}`, id, id)
+	case "xelatex":
+		// xdvipdfmx reads the /ID from a special, which must be shipped out
+		// with a page.
+		setup = fmt.Sprintf(`\AtBeginDocument{\special{pdf:trailerid [<%s> <%s>]}}`, id, id)
+	}
+
+	reproducible := *req
+	reproducible.Content = setup + "\n" + req.Content
+	return &reproducible
+}
+
+// reproducibleEnv is the environment that makes every engine stamp the PDF
+// dates, and \today, with opts.SourceDateEpoch instead of the current time.
+func reproducibleEnv(opts RenderOptions) []string {
+	return []string{
+		"SOURCE_DATE_EPOCH=" + strconv.FormatInt(opts.SourceDateEpoch, 10),
+		"FORCE_SOURCE_DATE=1",
+	}
+}
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// The sanitizer's allow-lists, by namespace. Names are written in their
+// canonical case, which is what the sanitizer emits; SVG is case-sensitive
+// while the tokenizer lowercases every name.
+var (
+	htmlElements = allowList(`a abbr article aside b bdi bdo blockquote body br caption cite code col colgroup
+		dd del details dfn div dl dt em figcaption figure footer h1 h2 h3 h4 h5 h6 head header hr html i img ins
+		kbd li main mark meta nav ol p pre q rp rt ruby s samp section small span strong sub summary sup table
+		tbody td tfoot th thead time title tr u ul var wbr`)
+
+	htmlAttributes = allowList(`align alt charset cite colspan content datetime dir headers height href lang
+		open reversed role rowspan scope span src start title type valign value width`)
+
+	mathElements = allowList(`math annotation annotation-xml menclose merror mfenced mfrac mglyph mi
+		mlabeledtr mmultiscripts mn mo mover mpadded mphantom mprescripts mroot mrow ms mspace msqrt mstyle
+		msub msubsup msup mtable mtd mtext mtr munder munderover none semantics
+		abs and apply approx arccos arccosh arccot arccoth arccsc arccsch arcsec arcsech arcsin arcsinh arctan
+		arctanh arg bind bvar card cartesianproduct cbytes ceiling cerror ci cn codomain complexes compose
+		condition conjugate cos cosh cot coth cs csc csch csymbol curl declare degree determinant diff
+		divergence divide domain domainofapplication emptyset eq equivalent eulergamma exists exp exponentiale
+		factorial factorof false floor fn forall gcd geq grad gt ident image imaginary imaginaryi implies in
+		infinity int integers intersect interval inverse lambda laplacian lcm leq limit list ln log logbase
+		lowlimit lt matrix matrixrow max mean median min minus mode moment momentabout naturalnumbers neq not
+		notanumber notin notprsubset notsubset or otherwise outerproduct partialdiff pi piece piecewise plus
+		power primes product prsubset quotient rationals real reals reln rem root scalarproduct sdev sec sech
+		selector sep set setdiff share sin sinh subset sum tan tanh tendsto times transpose true union uplimit
+		variance vector vectorproduct xor`)
+
+	mathAttributes = allowList(`accent accentunder align alttext base bevelled cd close columnalign
+		columnlines columnspacing columnspan denomalign depth dir display displaystyle encoding equalcolumns
+		equalrows fence form frame framespacing height href indentalign largeop linebreak linethickness lspace
+		mathbackground mathcolor mathsize mathvariant maxsize minsize movablelimits name notation numalign open
+		rowalign rowlines rowspacing rowspan rspace scriptlevel separator separators side stretchy symmetric
+		type voffset width xref`)
+
+	// SVG animation elements are left out: they can set an href to a
+	// javascript: URL.
+	svgElements = allowList(`svg a circle clipPath defs desc ellipse foreignObject g image line
+		linearGradient marker mask path pattern polygon polyline radialGradient rect stop symbol text textPath
+		title tspan use`)
+
+	svgAttributes = allowList(`baseline-shift clip-path clip-rule clipPathUnits color cx cy d display
+		dominant-baseline dx dy fill fill-opacity fill-rule font-family font-size font-style font-weight fx fy
+		gradientTransform gradientUnits height href lengthAdjust letter-spacing marker-end marker-mid
+		marker-start markerHeight markerUnits markerWidth mask maskContentUnits maskUnits offset opacity orient
+		overflow path pathLength patternContentUnits patternTransform patternUnits points preserveAspectRatio r
+		refX refY rotate rx ry spreadMethod startOffset stop-color stop-opacity stroke stroke-dasharray
+		stroke-dashoffset stroke-linecap stroke-linejoin stroke-miterlimit stroke-opacity stroke-width
+		text-anchor text-decoration textLength transform version vector-effect viewBox visibility width
+		word-spacing writing-mode x x1 x2 xlink:href y y1 y2`)
+
+	// commonAttributes are allowed on elements of every namespace.
+	commonAttributes = allowList(`class id style`)
+
+	// droppedWithContent are elements removed along with everything inside
+	// them; other elements outside the allow-lists lose their tags only.
+	droppedWithContent = allowList(`applet embed frame frameset iframe noembed noframes noscript object
+		plaintext script select style template textarea xmp`)
+
+	// voidElements never have content or an end tag in HTML.
+	voidElements = allowList(`br col hr img meta wbr`)
+
+	// breakoutElements close any open SVG or MathML element when they start
+	// inside foreign content, as browsers do.
+	breakoutElements = allowList(`b big blockquote body br center code dd div dl dt em embed h1 h2 h3 h4 h5
+		h6 head hr i img li listing menu meta nobr ol p pre ruby s small span strike strong sub sup table tt u
+		ul var`)
+
+	// urlAttributes hold URLs, filtered by scheme.
+	urlAttributes = allowList(`cite href src xlink:href`)
+
+	// safeSchemes are the URL schemes allowed in links. Relative URLs and
+	// fragments have none.
+	safeSchemes = []string{"http", "https", "mailto"}
+
+	// dataImage matches the data URIs allowed as image sources.
+	dataImage = regexp.MustCompile(`^data:image/(?:png|jpeg|gif|webp|svg\+xml)[;,]`)
+
+	// unsafeStyle matches style values that can load resources or run code.
+	unsafeStyle = regexp.MustCompile(`(?i)url\s*\(|expression\s*\(|javascript:|@import|\\|<`)
+
+	xmlNamespaces = []string{"http://www.w3.org/1999/xhtml", "http://www.w3.org/1998/Math/MathML",
+		"http://www.w3.org/2000/svg", "http://www.w3.org/1999/xlink"}
+)
+
+// allowList maps the lowercased form of each name in a space-separated list
+// to the name itself.
+func allowList(names string) map[string]string {
+	list := map[string]string{}
+	for _, name := range strings.Fields(names) {
+		list[strings.ToLower(name)] = name
+	}
+	return list
+}
+
+type namespace int
+
+const (
+	nsHTML namespace = iota
+	nsMath
+	nsSVG
+)
+
+// openElement is an element the sanitizer has written and not yet closed.
+type openElement struct {
+	name string
+	ns   namespace
+}
+
+// sanitizeHTML rewrites LaTeXML output so that it is safe to embed in a page
+// from untrusted LaTeX: only allow-listed HTML, MathML and SVG elements and
+// attributes are kept, links are limited to safe URL schemes, and comments,
+// event handlers and scripts are dropped. With stripIDs, id attributes are
+// dropped too, so that the markup cannot clobber the host page's globals.
+//
+// Every element written is closed by the end of the output, so that the
+// markup cannot swallow the page it is embedded in.
+func sanitizeHTML(page []byte, stripIDs bool) []byte {
+	var out bytes.Buffer
+	var open []openElement
+	closeTo := func(n int) {
+		for _, e := range slices.Backward(open[n:]) {
+			out.WriteString("</" + e.name + ">")
+		}
+		open = open[:n]
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// bytes.Reader cannot fail, so this is the end of the page.
+			closeTo(0)
+			return out.Bytes()
+
+		case html.TextToken:
+			out.WriteString(html.EscapeString(string(z.Text())))
+
+		case html.DoctypeToken:
+			out.WriteString("<!DOCTYPE html>")
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			lower := string(name)
+			if _, ok := droppedWithContent[lower]; ok {
+				if tt == html.StartTagToken {
+					skipElement(z, lower)
+				}
+				continue
+			}
+
+			ns := childNamespace(open, lower)
+			if _, ok := breakoutElements[lower]; ok && ns != nsHTML {
+				n := len(open)
+				for n > 0 && childNamespace(open[:n], lower) != nsHTML {
+					n--
+				}
+				closeTo(n)
+				ns = nsHTML
+			}
+			canonical, ok := elementAllowList(ns)[lower]
+			if !ok {
+				continue
+			}
+
+			out.WriteString("<" + canonical)
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if attr, ok := sanitizeAttribute(ns, lower, string(key), string(val), stripIDs); ok {
+					out.WriteString(" " + attr + `="` + html.EscapeString(string(val)) + `"`)
+				}
+			}
+
+			_, void := voidElements[lower]
+			switch {
+			case void && ns == nsHTML:
+				out.WriteString(">")
+			case tt == html.SelfClosingTagToken && ns != nsHTML:
+				out.WriteString("/>")
+			default:
+				out.WriteString(">")
+				open = append(open, openElement{canonical, ns})
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			lower := string(name)
+			n := len(open) - 1
+			for n >= 0 && strings.ToLower(open[n].name) != lower {
+				n--
+			}
+			if n >= 0 {
+				// Close the element and any left open inside it.
+				closeTo(n)
+			}
+		}
+	}
+}
+
+// skipElement consumes the tokens of an element, up to the end tag matching
+// the start tag just read.
+func skipElement(z *html.Tokenizer, name string) {
+	depth := 1
+	for depth > 0 {
+		switch z.Next() {
+		case html.ErrorToken:
+			return
+		case html.StartTagToken:
+			if tag, _ := z.TagName(); string(tag) == name {
+				depth++
+			}
+		case html.EndTagToken:
+			if tag, _ := z.TagName(); string(tag) == name {
+				depth--
+			}
+		}
+	}
+}
+
+// childNamespace returns the namespace a browser gives an element named
+// name that starts inside the open elements.
+func childNamespace(open []openElement, name string) namespace {
+	ns := nsHTML
+	if len(open) > 0 {
+		parent := open[len(open)-1]
+		ns = parent.ns
+		switch {
+		case parent.ns == nsSVG && slices.Contains([]string{"foreignObject", "desc", "title"}, parent.name):
+			ns = nsHTML
+		case parent.ns == nsMath && slices.Contains([]string{"mi", "mo", "mn", "ms", "mtext"}, parent.name) &&
+			name != "mglyph" && name != "malignmark":
+			ns = nsHTML
+		case parent.name == "annotation-xml" && name == "svg":
+			return nsSVG
+		}
+	}
+	if ns == nsHTML {
+		switch name {
+		case "svg":
+			return nsSVG
+		case "math":
+			return nsMath
+		}
+	}
+	return ns
+}
+
+func elementAllowList(ns namespace) map[string]string {
+	switch ns {
+	case nsMath:
+		return mathElements
+	case nsSVG:
+		return svgElements
+	}
+	return htmlElements
+}
+
+func attributeAllowList(ns namespace) map[string]string {
+	switch ns {
+	case nsMath:
+		return mathAttributes
+	case nsSVG:
+		return svgAttributes
+	}
+	return htmlAttributes
+}
+
+// sanitizeAttribute returns the canonical name of an attribute of element,
+// and reports whether the attribute is allowed with that value.
+func sanitizeAttribute(ns namespace, element, key, val string, stripIDs bool) (string, bool) {
+	if key == "id" && stripIDs {
+		return "", false
+	}
+	if key == "xmlns" || key == "xmlns:xlink" {
+		return key, slices.Contains(xmlNamespaces, val)
+	}
+
+	name, ok := commonAttributes[key]
+	if !ok {
+		name, ok = attributeAllowList(ns)[key]
+	}
+	if !ok && ns == nsHTML && strings.HasPrefix(key, "aria-") {
+		name, ok = key, true
+	}
+	if !ok {
+		return "", false
+	}
+
+	if _, isURL := urlAttributes[key]; isURL {
+		image := key == "src" || element == "image"
+		if !safeURL(val, image) {
+			return "", false
+		}
+	}
+	if key == "style" && unsafeStyle.MatchString(val) {
+		return "", false
+	}
+	return name, true
+}
+
+// safeURL reports whether u is relative or uses a safe scheme. Images may
+// also be data URIs of image types.
+func safeURL(u string, image bool) bool {
+	// Browsers ignore control characters and whitespace inside a scheme, as
+	// in "java\tscript:".
+	u = strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u))
+	if image && dataImage.MatchString(u) {
+		return true
+	}
+	scheme, _, found := strings.Cut(u, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+	return slices.Contains(safeSchemes, scheme)
+}
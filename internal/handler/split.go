@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"encoding/json"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"latex-renderer/internal/artifact"
+)
+
+// splitLevels are the sectional units latexmlpost can split a document at.
+var splitLevels = []string{"part", "chapter", "section", "subsection"}
+
+var (
+	anchorTag = regexp.MustCompile(`<(?:section|figure|table)\b[^>]*\sid="([^"]+)"`)
+	titleTag  = regexp.MustCompile(`(?s)<h[1-6]\b[^>]*class="[^"]*\bltx_title\b[^"]*"[^>]*>(.*?)</h[1-6]>`)
+	markupTag = regexp.MustCompile(`<[^>]*>`)
+	linkTag   = regexp.MustCompile(`<a\b[^>]*>`)
+	relNext   = regexp.MustCompile(`\srel="[^"]*\bnext\b[^"]*"`)
+	pageHref  = regexp.MustCompile(`\shref="([^"#:]*\.html)(#[^"]*)?"`)
+)
+
+// manifestFile is the name of the manifest in the zip of a split render.
+const manifestFile = "manifest.json"
+
+// HTMLManifest lists the pages of an HTML render split by section, in
+// reading order.
+type HTMLManifest struct {
+	Pages []HTMLPage `json:"pages"`
+}
+
+// HTMLPage is one page of a split HTML render.
+type HTMLPage struct {
+	Order int    `json:"order" example:"1"`
+	Title string `json:"title" example:"2 Related work"`
+	// File is the page's name inside the zip; links between pages use it.
+	File string `json:"file" example:"S2.html"`
+	// URL is the absolute URL of the page in the artifact store, with
+	// bundle=links.
+	URL string `json:"url,omitempty" example:"https://latex.example.com/artifacts/3f1c2a4e-8b7d-4e59-9a61-0c2d5e8f7b10"`
+	// Anchors are the ids of the sections, figures, tables and equations on
+	// the page, to link to as URL#anchor.
+	Anchors []string `json:"anchors"`
+	// Prev and Next are the File of the neighbouring pages.
+	Prev string `json:"prev,omitempty" example:"S1.html"`
+	Next string `json:"next,omitempty" example:"S3.html"`
+}
+
+// splitHTML collects the pages latexmlc split the document into and returns
+// them with a manifest: in a zip with bundle=zip, or stored as artifacts with
+// bundle=links, in which case the response is the manifest alone. Links
+// between pages are rewritten to work in either form.
+func splitHTML(j *job, req *RenderReq) ([]byte, string, error) {
+	root := filepath.Base(j.path(".html"))
+	files, sources, err := splitPages(j.dir, root)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The root page becomes index.html; the others keep latexmlc's names.
+	names := map[string]string{}
+	targets := map[string]string{}
+	ids := map[string]string{}
+	for _, file := range files {
+		names[file] = file
+		if file == root {
+			names[file] = "index.html"
+		}
+		targets[file] = names[file]
+		if req.Options.Bundle == "links" {
+			ids[file] = artifact.NewID()
+			targets[file] = "/artifacts/" + ids[file]
+		}
+	}
+
+	manifest := HTMLManifest{Pages: make([]HTMLPage, len(files))}
+	pages := make([][]byte, len(files))
+	images := map[string]string{}
+	for i, file := range files {
+		page := sources[file]
+		entry := HTMLPage{Order: i + 1, Title: pageTitle(page, file), File: names[file], Anchors: []string{}}
+		for _, m := range anchorTag.FindAllSubmatch(page, -1) {
+			entry.Anchors = append(entry.Anchors, html.UnescapeString(string(m[1])))
+		}
+		if i > 0 {
+			entry.Prev = names[files[i-1]]
+		}
+		if i < len(files)-1 {
+			entry.Next = names[files[i+1]]
+		}
+
+		page = pageHref.ReplaceAllFunc(page, func(attr []byte) []byte {
+			m := pageHref.FindSubmatch(attr)
+			target, ok := targets[path.Clean(string(m[1]))]
+			if !ok {
+				return attr
+			}
+			return []byte(` href="` + target + string(m[2]) + `"`)
+		})
+		page, pageImages, err := finishPage(page, j, req)
+		if err != nil {
+			return nil, "", err
+		}
+		for name, file := range pageImages {
+			images[name] = file
+		}
+
+		if req.Options.Bundle == "links" {
+			if err := artifacts.PutID(ids[file], names[file], "text/html; charset=utf-8", page); err != nil {
+				return nil, "", internalError("cannot store artifact")
+			}
+			entry.URL = req.baseURL + targets[file]
+		}
+		manifest.Pages[i], pages[i] = entry, page
+	}
+
+	out, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", internalError("cannot encode response")
+	}
+	if req.Options.Bundle == "links" {
+		return out, "application/json; charset=utf-8", nil
+	}
+
+	zipNames := []string{manifestFile}
+	for _, page := range manifest.Pages {
+		zipNames = append(zipNames, page.File)
+	}
+	archive, err := zipOutput(zipNames, append([][]byte{out}, pages...), images)
+	return archive, "application/zip", err
+}
+
+// splitPages orders the pages of a split document: the chain of rel="next"
+// links from root, then any other page of dir they link to. It returns the
+// page files, relative to dir, with their content.
+func splitPages(dir, root string) ([]string, map[string][]byte, error) {
+	sources := map[string][]byte{}
+	read := func(file string) bool {
+		if _, ok := sources[file]; ok {
+			return false
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return false
+		}
+		sources[file] = data
+		return true
+	}
+	if !read(root) {
+		return nil, nil, internalError("cannot read output")
+	}
+
+	files := []string{root}
+	for next := nextPage(sources[root]); next != "" && read(next); next = nextPage(sources[next]) {
+		files = append(files, next)
+	}
+	for i := 0; i < len(files); i++ {
+		for _, m := range pageHref.FindAllSubmatch(sources[files[i]], -1) {
+			if file := pageFile(string(m[1])); file != "" && read(file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, sources, nil
+}
+
+// nextPage returns the file that page's rel="next" navigation link points to.
+func nextPage(page []byte) string {
+	for _, tag := range linkTag.FindAll(page, -1) {
+		if !relNext.Match(tag) {
+			continue
+		}
+		if m := pageHref.FindSubmatch(tag); m != nil {
+			return pageFile(string(m[1]))
+		}
+	}
+	return ""
+}
+
+// pageFile cleans a link to a page of the job directory, returning "" for
+// links that leave it.
+func pageFile(href string) string {
+	file := path.Clean(href)
+	if path.IsAbs(file) || file == ".." || strings.HasPrefix(file, "../") {
+		return ""
+	}
+	return file
+}
+
+// pageTitle is the text of the page's first LaTeXML heading, or of its
+// <title>, or the file name.
+func pageTitle(page []byte, file string) string {
+	m := titleTag.FindSubmatch(page)
+	if m == nil {
+		m = htmlTitle.FindSubmatch(page)
+	}
+	if m != nil {
+		text := html.UnescapeString(markupTag.ReplaceAllString(string(m[1]), " "))
+		if title := strings.Join(strings.Fields(text), " "); title != "" {
+			return title
+		}
+	}
+	return file
+}
@@ -0,0 +1,48 @@
+#!/usr/bin/env perl
+# Long-lived LaTeXML math converter. LaTeXML and its bindings are loaded once;
+# each stdin line is a JSON request {"tex": "..."} and each stdout line the
+# matching JSON response {"result", "status_code", "log"}. The first line
+# written is {"ready": true} once the session is prepared.
+use strict;
+use warnings;
+use JSON::XS;
+use LaTeXML;
+use LaTeXML::Common::Config;
+
+# LaTeXML may print to STDOUT; keep the protocol channel to ourselves.
+open(my $out, '>&', \*STDOUT) or die "cannot dup stdout: $!";
+open(STDOUT, '>&', \*STDERR) or die "cannot redirect stdout: $!";
+$out->autoflush(1);
+
+my $json = JSON::XS->new->utf8->canonical;
+
+my $config = LaTeXML::Common::Config->new(
+  whatsin   => 'math',
+  whatsout  => 'math',
+  format    => 'html5',
+  post      => 1,
+  pmml      => 1,
+  preload   => ['LaTeX.pool', 'amsmath.sty', 'amssymb.sty'],
+  timeout   => 10,
+  verbosity => -1,
+);
+my $converter = LaTeXML->get_converter($config);
+$converter->prepare_session($config);
+
+print $out $json->encode({ ready => JSON::XS::true }), "\n";
+
+while (my $line = <STDIN>) {
+  my $request = eval { $json->decode($line) };
+  my $response;
+  if (!$request || !defined $$request{tex}) {
+    $response = { status_code => 3, log => 'invalid request' };
+  } else {
+    my $result = $converter->convert('literal:' . $$request{tex});
+    $response = {
+      result      => $$result{result},
+      status_code => $$result{status_code},
+      log         => $$result{log},
+    };
+  }
+  print $out $json->encode($response), "\n";
+}
@@ -0,0 +1,483 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<!--
+  Transforms LaTeXML's XML output, post-processed with presentation MathML,
+  into a JATS 1.3 Archiving and Interchange article with MathML 3.
+
+  Front matter comes from the document's title, authors, keywords and
+  abstract; sections, lists, figures, tables and equations become the body;
+  the bibliography and appendices become the back matter. Math keeps its TeX
+  source next to the MathML when LaTeXML recorded it.
+-->
+<xsl:stylesheet version="1.0"
+    xmlns:xsl="http://www.w3.org/1999/XSL/Transform"
+    xmlns:ltx="http://dlmf.nist.gov/LaTeXML"
+    xmlns:mml="http://www.w3.org/1998/Math/MathML"
+    xmlns:xlink="http://www.w3.org/1999/xlink"
+    exclude-result-prefixes="ltx">
+
+  <xsl:output method="xml" encoding="UTF-8" indent="no"
+      doctype-public="-//NLM//DTD JATS (Z39.96) Journal Archiving and Interchange DTD with MathML3 v1.3 20210610//EN"
+      doctype-system="JATS-archivearticle1-3-mathml3.dtd"/>
+
+  <xsl:strip-space elements="ltx:*"/>
+
+  <xsl:template match="/">
+    <xsl:apply-templates select="ltx:document"/>
+  </xsl:template>
+
+  <xsl:template match="ltx:document">
+    <article article-type="research-article" dtd-version="1.3">
+      <xsl:copy-of select="@xml:lang"/>
+      <front>
+        <article-meta>
+          <title-group>
+            <article-title>
+              <xsl:apply-templates select="ltx:title/node()"/>
+            </article-title>
+            <xsl:if test="ltx:subtitle">
+              <subtitle>
+                <xsl:apply-templates select="ltx:subtitle/node()"/>
+              </subtitle>
+            </xsl:if>
+          </title-group>
+          <xsl:if test="ltx:creator[@role='author']">
+            <contrib-group>
+              <xsl:apply-templates select="ltx:creator[@role='author']"/>
+            </contrib-group>
+          </xsl:if>
+          <xsl:if test="ltx:date[@role='creation']">
+            <pub-date>
+              <string-date>
+                <xsl:value-of select="normalize-space(ltx:date[@role='creation'])"/>
+              </string-date>
+            </pub-date>
+          </xsl:if>
+          <xsl:apply-templates select="ltx:abstract"/>
+          <xsl:if test="ltx:keywords">
+            <kwd-group>
+              <xsl:call-template name="keywords">
+                <xsl:with-param name="list" select="normalize-space(ltx:keywords)"/>
+              </xsl:call-template>
+            </kwd-group>
+          </xsl:if>
+        </article-meta>
+      </front>
+      <body>
+        <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:subtitle or self::ltx:creator
+            or self::ltx:date or self::ltx:abstract or self::ltx:keywords or self::ltx:classification
+            or self::ltx:bibliography or self::ltx:appendix or self::ltx:acknowledgements
+            or self::ltx:TOC or self::ltx:resource or self::ltx:tags)]"/>
+      </body>
+      <xsl:if test="ltx:bibliography or ltx:appendix or ltx:acknowledgements">
+        <back>
+          <xsl:apply-templates select="ltx:acknowledgements"/>
+          <xsl:if test="ltx:appendix">
+            <app-group>
+              <xsl:apply-templates select="ltx:appendix"/>
+            </app-group>
+          </xsl:if>
+          <xsl:apply-templates select="ltx:bibliography"/>
+        </back>
+      </xsl:if>
+    </article>
+  </xsl:template>
+
+  <!-- Front matter -->
+
+  <xsl:template match="ltx:creator">
+    <contrib contrib-type="author">
+      <string-name>
+        <xsl:value-of select="normalize-space(ltx:personname)"/>
+      </string-name>
+      <xsl:for-each select="ltx:contact[@role='email']">
+        <email><xsl:value-of select="normalize-space(.)"/></email>
+      </xsl:for-each>
+      <xsl:for-each select="ltx:contact[@role='affiliation' or @role='address']">
+        <aff><xsl:value-of select="normalize-space(.)"/></aff>
+      </xsl:for-each>
+    </contrib>
+  </xsl:template>
+
+  <xsl:template match="ltx:abstract">
+    <abstract>
+      <xsl:apply-templates select="*[not(self::ltx:title)]"/>
+    </abstract>
+  </xsl:template>
+
+  <!-- keywords splits a comma-separated list into kwd elements. -->
+  <xsl:template name="keywords">
+    <xsl:param name="list"/>
+    <xsl:variable name="head" select="normalize-space(substring-before(concat($list, ','), ','))"/>
+    <xsl:if test="$head != ''">
+      <kwd><xsl:value-of select="$head"/></kwd>
+    </xsl:if>
+    <xsl:if test="contains($list, ',')">
+      <xsl:call-template name="keywords">
+        <xsl:with-param name="list" select="substring-after($list, ',')"/>
+      </xsl:call-template>
+    </xsl:if>
+  </xsl:template>
+
+  <!-- Sections -->
+
+  <xsl:template match="ltx:part | ltx:chapter | ltx:section | ltx:subsection | ltx:subsubsection
+      | ltx:paragraph | ltx:subparagraph">
+    <sec>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <title>
+        <xsl:apply-templates select="ltx:title/node()"/>
+      </title>
+      <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:tags)]"/>
+    </sec>
+  </xsl:template>
+
+  <xsl:template match="ltx:appendix">
+    <app>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <title>
+        <xsl:apply-templates select="ltx:title/node()"/>
+      </title>
+      <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:tags)]"/>
+    </app>
+  </xsl:template>
+
+  <xsl:template match="ltx:acknowledgements">
+    <ack>
+      <xsl:apply-templates/>
+    </ack>
+  </xsl:template>
+
+  <xsl:template match="ltx:theorem | ltx:proof">
+    <statement>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <xsl:if test="ltx:title">
+        <title>
+          <xsl:apply-templates select="ltx:title/node()"/>
+        </title>
+      </xsl:if>
+      <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:tags)]"/>
+    </statement>
+  </xsl:template>
+
+  <!-- Paragraphs group their blocks in LaTeXML; JATS has no such level. -->
+  <xsl:template match="ltx:para | ltx:inline-para">
+    <xsl:apply-templates/>
+  </xsl:template>
+
+  <xsl:template match="ltx:p">
+    <p>
+      <xsl:apply-templates/>
+    </p>
+  </xsl:template>
+
+  <xsl:template match="ltx:quote">
+    <disp-quote>
+      <xsl:apply-templates/>
+    </disp-quote>
+  </xsl:template>
+
+  <xsl:template match="ltx:verbatim">
+    <preformat>
+      <xsl:value-of select="."/>
+    </preformat>
+  </xsl:template>
+
+  <!-- Lists -->
+
+  <xsl:template match="ltx:itemize | ltx:enumerate">
+    <list>
+      <xsl:attribute name="list-type">
+        <xsl:choose>
+          <xsl:when test="self::ltx:enumerate">order</xsl:when>
+          <xsl:otherwise>bullet</xsl:otherwise>
+        </xsl:choose>
+      </xsl:attribute>
+      <xsl:apply-templates select="ltx:item"/>
+    </list>
+  </xsl:template>
+
+  <xsl:template match="ltx:item">
+    <list-item>
+      <xsl:call-template name="id"/>
+      <xsl:apply-templates/>
+    </list-item>
+  </xsl:template>
+
+  <xsl:template match="ltx:description">
+    <def-list>
+      <xsl:for-each select="ltx:item">
+        <def-item>
+          <term>
+            <xsl:apply-templates select="(ltx:tags/ltx:tag[not(@role)] | ltx:tag)[1]/node()"/>
+          </term>
+          <def>
+            <xsl:apply-templates select="*[not(self::ltx:tag or self::ltx:tags)]"/>
+          </def>
+        </def-item>
+      </xsl:for-each>
+    </def-list>
+  </xsl:template>
+
+  <!-- Floats -->
+
+  <xsl:template match="ltx:figure">
+    <fig>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <xsl:apply-templates select="ltx:caption"/>
+      <xsl:apply-templates select=".//ltx:graphics"/>
+    </fig>
+  </xsl:template>
+
+  <xsl:template match="ltx:table">
+    <table-wrap>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <xsl:apply-templates select="ltx:caption"/>
+      <xsl:apply-templates select=".//ltx:tabular"/>
+    </table-wrap>
+  </xsl:template>
+
+  <xsl:template match="ltx:caption">
+    <caption>
+      <p>
+        <xsl:apply-templates/>
+      </p>
+    </caption>
+  </xsl:template>
+
+  <xsl:template match="ltx:graphics">
+    <graphic xlink:href="{@graphic}"/>
+  </xsl:template>
+
+  <xsl:template match="ltx:tabular">
+    <table>
+      <xsl:choose>
+        <xsl:when test="ltx:thead or ltx:tbody or ltx:tfoot">
+          <xsl:apply-templates select="ltx:thead"/>
+          <xsl:apply-templates select="ltx:tbody"/>
+          <xsl:apply-templates select="ltx:tfoot"/>
+        </xsl:when>
+        <xsl:otherwise>
+          <tbody>
+            <xsl:apply-templates select="ltx:tr"/>
+          </tbody>
+        </xsl:otherwise>
+      </xsl:choose>
+    </table>
+  </xsl:template>
+
+  <xsl:template match="ltx:thead">
+    <thead><xsl:apply-templates select="ltx:tr"/></thead>
+  </xsl:template>
+
+  <xsl:template match="ltx:tbody">
+    <tbody><xsl:apply-templates select="ltx:tr"/></tbody>
+  </xsl:template>
+
+  <xsl:template match="ltx:tfoot">
+    <tfoot><xsl:apply-templates select="ltx:tr"/></tfoot>
+  </xsl:template>
+
+  <xsl:template match="ltx:tr">
+    <tr><xsl:apply-templates select="ltx:td"/></tr>
+  </xsl:template>
+
+  <xsl:template match="ltx:td">
+    <xsl:variable name="cell">
+      <xsl:choose>
+        <xsl:when test="@thead">th</xsl:when>
+        <xsl:otherwise>td</xsl:otherwise>
+      </xsl:choose>
+    </xsl:variable>
+    <xsl:element name="{$cell}">
+      <xsl:copy-of select="@colspan | @rowspan"/>
+      <xsl:if test="@align">
+        <xsl:attribute name="align"><xsl:value-of select="@align"/></xsl:attribute>
+      </xsl:if>
+      <xsl:apply-templates/>
+    </xsl:element>
+  </xsl:template>
+
+  <!-- Math -->
+
+  <xsl:template match="ltx:equationgroup">
+    <xsl:apply-templates select="ltx:equation | ltx:equationgroup"/>
+  </xsl:template>
+
+  <xsl:template match="ltx:equation">
+    <disp-formula>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <xsl:for-each select="(ltx:Math | ltx:MathFork/ltx:Math)[1]">
+        <xsl:call-template name="formula"/>
+      </xsl:for-each>
+    </disp-formula>
+  </xsl:template>
+
+  <xsl:template match="ltx:Math">
+    <inline-formula>
+      <xsl:call-template name="formula"/>
+    </inline-formula>
+  </xsl:template>
+
+  <!-- formula writes the MathML of the current ltx:Math, with its TeX as
+       an alternative when LaTeXML recorded it. -->
+  <xsl:template name="formula">
+    <xsl:choose>
+      <xsl:when test="@tex">
+        <alternatives>
+          <xsl:apply-templates select="mml:math" mode="mml"/>
+          <tex-math><xsl:value-of select="@tex"/></tex-math>
+        </alternatives>
+      </xsl:when>
+      <xsl:otherwise>
+        <xsl:apply-templates select="mml:math" mode="mml"/>
+      </xsl:otherwise>
+    </xsl:choose>
+  </xsl:template>
+
+  <!-- The JATS DTD matches MathML by the mml: prefix, so every element is
+       rewritten with it. -->
+  <xsl:template match="mml:*" mode="mml">
+    <xsl:element name="mml:{local-name()}" namespace="http://www.w3.org/1998/Math/MathML">
+      <xsl:copy-of select="@*[namespace-uri() = '']"/>
+      <xsl:apply-templates mode="mml"/>
+    </xsl:element>
+  </xsl:template>
+
+  <!-- Inline markup -->
+
+  <xsl:template match="ltx:emph">
+    <italic><xsl:apply-templates/></italic>
+  </xsl:template>
+
+  <xsl:template match="ltx:text">
+    <xsl:choose>
+      <xsl:when test="contains(@font, 'bold')">
+        <bold><xsl:apply-templates/></bold>
+      </xsl:when>
+      <xsl:when test="contains(@font, 'italic') or contains(@font, 'slanted')">
+        <italic><xsl:apply-templates/></italic>
+      </xsl:when>
+      <xsl:when test="contains(@font, 'typewriter')">
+        <monospace><xsl:apply-templates/></monospace>
+      </xsl:when>
+      <xsl:when test="contains(@font, 'smallcaps')">
+        <sc><xsl:apply-templates/></sc>
+      </xsl:when>
+      <xsl:otherwise>
+        <xsl:apply-templates/>
+      </xsl:otherwise>
+    </xsl:choose>
+  </xsl:template>
+
+  <xsl:template match="ltx:sup">
+    <sup><xsl:apply-templates/></sup>
+  </xsl:template>
+
+  <xsl:template match="ltx:sub">
+    <sub><xsl:apply-templates/></sub>
+  </xsl:template>
+
+  <xsl:template match="ltx:ref[@href]">
+    <ext-link ext-link-type="uri" xlink:href="{@href}">
+      <xsl:apply-templates/>
+      <xsl:if test="not(node())"><xsl:value-of select="@href"/></xsl:if>
+    </ext-link>
+  </xsl:template>
+
+  <xsl:template match="ltx:ref[@idref]">
+    <xref rid="{@idref}">
+      <xsl:attribute name="ref-type">
+        <xsl:call-template name="ref-type">
+          <xsl:with-param name="target" select="key('ids', @idref)"/>
+        </xsl:call-template>
+      </xsl:attribute>
+      <xsl:apply-templates/>
+    </xref>
+  </xsl:template>
+
+  <xsl:key name="ids" match="*[@xml:id]" use="@xml:id"/>
+
+  <xsl:template name="ref-type">
+    <xsl:param name="target"/>
+    <xsl:choose>
+      <xsl:when test="$target/self::ltx:bibitem">bibr</xsl:when>
+      <xsl:when test="$target/self::ltx:figure">fig</xsl:when>
+      <xsl:when test="$target/self::ltx:table">table</xsl:when>
+      <xsl:when test="$target/self::ltx:equation">disp-formula</xsl:when>
+      <xsl:when test="$target/self::ltx:note">fn</xsl:when>
+      <xsl:when test="$target/self::ltx:appendix">app</xsl:when>
+      <xsl:when test="$target/self::ltx:item">list</xsl:when>
+      <xsl:when test="$target/self::ltx:theorem or $target/self::ltx:proof">statement</xsl:when>
+      <xsl:otherwise>sec</xsl:otherwise>
+    </xsl:choose>
+  </xsl:template>
+
+  <xsl:template match="ltx:note[@role='footnote']">
+    <fn>
+      <xsl:call-template name="id"/>
+      <p><xsl:apply-templates select="node()[not(self::ltx:tag or self::ltx:tags)]"/></p>
+    </fn>
+  </xsl:template>
+
+  <xsl:template match="ltx:break">
+    <xsl:text> </xsl:text>
+  </xsl:template>
+
+  <!-- Bibliography -->
+
+  <xsl:template match="ltx:bibliography">
+    <ref-list>
+      <xsl:if test="ltx:title">
+        <title><xsl:apply-templates select="ltx:title/node()"/></title>
+      </xsl:if>
+      <xsl:apply-templates select=".//ltx:bibitem"/>
+    </ref-list>
+  </xsl:template>
+
+  <xsl:template match="ltx:bibitem">
+    <ref>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="label"/>
+      <mixed-citation>
+        <xsl:for-each select="ltx:bibblock">
+          <xsl:if test="position() > 1"><xsl:text> </xsl:text></xsl:if>
+          <xsl:apply-templates/>
+        </xsl:for-each>
+      </mixed-citation>
+    </ref>
+  </xsl:template>
+
+  <!-- Shared -->
+
+  <xsl:template name="id">
+    <xsl:if test="@xml:id">
+      <xsl:attribute name="id"><xsl:value-of select="@xml:id"/></xsl:attribute>
+    </xsl:if>
+  </xsl:template>
+
+  <!-- label writes the number LaTeXML gave the element, such as "1.2" for a
+       section or "(3)" for an equation. -->
+  <xsl:template name="label">
+    <xsl:variable name="tag" select="(ltx:tags/ltx:tag[not(@role)] | ltx:tag | ltx:title/ltx:tag
+        | ltx:caption/ltx:tag)[1]"/>
+    <xsl:if test="$tag">
+      <label><xsl:value-of select="normalize-space($tag)"/></label>
+    </xsl:if>
+  </xsl:template>
+
+  <!-- Numbers are written as labels, and what JATS has no place for is left
+       out; any other element keeps its content only. -->
+  <xsl:template match="ltx:tag | ltx:tags | ltx:TOC | ltx:resource | ltx:navigation | ltx:XMath
+      | ltx:indexmark | ltx:ERROR"/>
+
+  <xsl:template match="ltx:*">
+    <xsl:apply-templates/>
+  </xsl:template>
+
+</xsl:stylesheet>
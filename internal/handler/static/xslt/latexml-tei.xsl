@@ -0,0 +1,404 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<!--
+  Transforms LaTeXML's XML output, post-processed with presentation MathML,
+  into a TEI P5 document.
+
+  The title and authors fill the TEI header; the abstract is front matter,
+  sections become numbered divisions of the body and the bibliography and
+  appendices make up the back matter. Math is kept as MathML in formula.
+-->
+<xsl:stylesheet version="1.0"
+    xmlns:xsl="http://www.w3.org/1999/XSL/Transform"
+    xmlns:ltx="http://dlmf.nist.gov/LaTeXML"
+    xmlns:mml="http://www.w3.org/1998/Math/MathML"
+    xmlns="http://www.tei-c.org/ns/1.0"
+    exclude-result-prefixes="ltx">
+
+  <xsl:output method="xml" encoding="UTF-8" indent="no"/>
+
+  <xsl:strip-space elements="ltx:*"/>
+
+  <xsl:template match="/">
+    <xsl:apply-templates select="ltx:document"/>
+  </xsl:template>
+
+  <xsl:template match="ltx:document">
+    <TEI>
+      <xsl:copy-of select="@xml:lang"/>
+      <teiHeader>
+        <fileDesc>
+          <titleStmt>
+            <title>
+              <xsl:value-of select="normalize-space(ltx:title)"/>
+            </title>
+            <xsl:for-each select="ltx:creator[@role='author']">
+              <author>
+                <xsl:value-of select="normalize-space(ltx:personname)"/>
+              </author>
+            </xsl:for-each>
+          </titleStmt>
+          <publicationStmt>
+            <p>Converted from LaTeX with LaTeXML.</p>
+          </publicationStmt>
+          <sourceDesc>
+            <p>LaTeX source.</p>
+          </sourceDesc>
+        </fileDesc>
+        <xsl:if test="ltx:keywords">
+          <profileDesc>
+            <textClass>
+              <keywords>
+                <xsl:call-template name="keywords">
+                  <xsl:with-param name="list" select="normalize-space(ltx:keywords)"/>
+                </xsl:call-template>
+              </keywords>
+            </textClass>
+          </profileDesc>
+        </xsl:if>
+      </teiHeader>
+      <text>
+        <xsl:if test="ltx:abstract">
+          <front>
+            <xsl:apply-templates select="ltx:abstract"/>
+          </front>
+        </xsl:if>
+        <body>
+          <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:subtitle or self::ltx:creator
+              or self::ltx:date or self::ltx:abstract or self::ltx:keywords or self::ltx:classification
+              or self::ltx:bibliography or self::ltx:appendix or self::ltx:acknowledgements
+              or self::ltx:TOC or self::ltx:resource or self::ltx:tags)]"/>
+        </body>
+        <xsl:if test="ltx:bibliography or ltx:appendix or ltx:acknowledgements">
+          <back>
+            <xsl:apply-templates select="ltx:acknowledgements | ltx:appendix | ltx:bibliography"/>
+          </back>
+        </xsl:if>
+      </text>
+    </TEI>
+  </xsl:template>
+
+  <!-- Front matter -->
+
+  <xsl:template match="ltx:abstract">
+    <div type="abstract">
+      <xsl:apply-templates select="*[not(self::ltx:title)]"/>
+    </div>
+  </xsl:template>
+
+  <!-- keywords splits a comma-separated list into term elements. -->
+  <xsl:template name="keywords">
+    <xsl:param name="list"/>
+    <xsl:variable name="head" select="normalize-space(substring-before(concat($list, ','), ','))"/>
+    <xsl:if test="$head != ''">
+      <term><xsl:value-of select="$head"/></term>
+    </xsl:if>
+    <xsl:if test="contains($list, ',')">
+      <xsl:call-template name="keywords">
+        <xsl:with-param name="list" select="substring-after($list, ',')"/>
+      </xsl:call-template>
+    </xsl:if>
+  </xsl:template>
+
+  <!-- Sections -->
+
+  <xsl:template match="ltx:part | ltx:chapter | ltx:section | ltx:subsection | ltx:subsubsection
+      | ltx:paragraph | ltx:subparagraph | ltx:appendix | ltx:acknowledgements">
+    <div type="{local-name()}">
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="number"/>
+      <xsl:if test="ltx:title">
+        <head>
+          <xsl:apply-templates select="ltx:title/node()"/>
+        </head>
+      </xsl:if>
+      <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:tags)]"/>
+    </div>
+  </xsl:template>
+
+  <xsl:template match="ltx:theorem | ltx:proof">
+    <div type="{local-name()}">
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="number"/>
+      <xsl:if test="ltx:title">
+        <head>
+          <xsl:apply-templates select="ltx:title/node()"/>
+        </head>
+      </xsl:if>
+      <xsl:apply-templates select="*[not(self::ltx:title or self::ltx:tags)]"/>
+    </div>
+  </xsl:template>
+
+  <!-- Paragraphs group their blocks in LaTeXML; TEI has no such level. -->
+  <xsl:template match="ltx:para | ltx:inline-para">
+    <xsl:apply-templates/>
+  </xsl:template>
+
+  <xsl:template match="ltx:p">
+    <p>
+      <xsl:apply-templates/>
+    </p>
+  </xsl:template>
+
+  <xsl:template match="ltx:quote">
+    <quote>
+      <xsl:apply-templates/>
+    </quote>
+  </xsl:template>
+
+  <xsl:template match="ltx:verbatim">
+    <ab type="verbatim">
+      <xsl:value-of select="."/>
+    </ab>
+  </xsl:template>
+
+  <!-- Lists -->
+
+  <xsl:template match="ltx:itemize | ltx:enumerate">
+    <list>
+      <xsl:attribute name="rend">
+        <xsl:choose>
+          <xsl:when test="self::ltx:enumerate">numbered</xsl:when>
+          <xsl:otherwise>bulleted</xsl:otherwise>
+        </xsl:choose>
+      </xsl:attribute>
+      <xsl:apply-templates select="ltx:item"/>
+    </list>
+  </xsl:template>
+
+  <xsl:template match="ltx:description">
+    <list type="gloss">
+      <xsl:for-each select="ltx:item">
+        <label>
+          <xsl:apply-templates select="(ltx:tags/ltx:tag[not(@role)] | ltx:tag)[1]/node()"/>
+        </label>
+        <item>
+          <xsl:call-template name="id"/>
+          <xsl:apply-templates select="*[not(self::ltx:tag or self::ltx:tags)]"/>
+        </item>
+      </xsl:for-each>
+    </list>
+  </xsl:template>
+
+  <xsl:template match="ltx:item">
+    <item>
+      <xsl:call-template name="id"/>
+      <xsl:apply-templates/>
+    </item>
+  </xsl:template>
+
+  <!-- Floats -->
+
+  <xsl:template match="ltx:figure">
+    <figure>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="number"/>
+      <xsl:apply-templates select="ltx:caption"/>
+      <xsl:apply-templates select=".//ltx:graphics"/>
+    </figure>
+  </xsl:template>
+
+  <xsl:template match="ltx:table">
+    <xsl:choose>
+      <xsl:when test=".//ltx:tabular">
+        <xsl:for-each select=".//ltx:tabular[1]">
+          <table>
+            <xsl:for-each select="ancestor::ltx:table[1]">
+              <xsl:call-template name="id"/>
+              <xsl:call-template name="number"/>
+              <xsl:apply-templates select="ltx:caption"/>
+            </xsl:for-each>
+            <xsl:apply-templates select=".//ltx:tr"/>
+          </table>
+        </xsl:for-each>
+      </xsl:when>
+      <xsl:otherwise>
+        <figure type="table">
+          <xsl:call-template name="id"/>
+          <xsl:call-template name="number"/>
+          <xsl:apply-templates select="ltx:caption"/>
+        </figure>
+      </xsl:otherwise>
+    </xsl:choose>
+  </xsl:template>
+
+  <xsl:template match="ltx:caption">
+    <head>
+      <xsl:apply-templates/>
+    </head>
+  </xsl:template>
+
+  <xsl:template match="ltx:graphics">
+    <graphic url="{@graphic}"/>
+  </xsl:template>
+
+  <!-- A tabular outside a table float. -->
+  <xsl:template match="ltx:tabular">
+    <table>
+      <xsl:apply-templates select=".//ltx:tr"/>
+    </table>
+  </xsl:template>
+
+  <xsl:template match="ltx:tr">
+    <row>
+      <xsl:if test="parent::ltx:thead">
+        <xsl:attribute name="role">label</xsl:attribute>
+      </xsl:if>
+      <xsl:apply-templates select="ltx:td"/>
+    </row>
+  </xsl:template>
+
+  <xsl:template match="ltx:td">
+    <cell>
+      <xsl:if test="@thead">
+        <xsl:attribute name="role">label</xsl:attribute>
+      </xsl:if>
+      <xsl:if test="@colspan">
+        <xsl:attribute name="cols"><xsl:value-of select="@colspan"/></xsl:attribute>
+      </xsl:if>
+      <xsl:if test="@rowspan">
+        <xsl:attribute name="rows"><xsl:value-of select="@rowspan"/></xsl:attribute>
+      </xsl:if>
+      <xsl:apply-templates/>
+    </cell>
+  </xsl:template>
+
+  <!-- Math -->
+
+  <xsl:template match="ltx:equationgroup">
+    <xsl:apply-templates select="ltx:equation | ltx:equationgroup"/>
+  </xsl:template>
+
+  <!-- TEI places formulas in phrase-level content, so a displayed one gets
+       a paragraph of its own. -->
+  <xsl:template match="ltx:equation">
+    <p>
+      <formula notation="MathML" rend="display">
+        <xsl:call-template name="id"/>
+        <xsl:call-template name="number"/>
+        <xsl:apply-templates select="(ltx:Math | ltx:MathFork/ltx:Math)[1]/mml:math" mode="mml"/>
+      </formula>
+    </p>
+  </xsl:template>
+
+  <xsl:template match="ltx:Math">
+    <formula notation="MathML" rend="inline">
+      <xsl:apply-templates select="mml:math" mode="mml"/>
+    </formula>
+  </xsl:template>
+
+  <xsl:template match="mml:*" mode="mml">
+    <xsl:element name="{local-name()}" namespace="http://www.w3.org/1998/Math/MathML">
+      <xsl:copy-of select="@*[namespace-uri() = '']"/>
+      <xsl:apply-templates mode="mml"/>
+    </xsl:element>
+  </xsl:template>
+
+  <!-- Inline markup -->
+
+  <xsl:template match="ltx:emph">
+    <emph><xsl:apply-templates/></emph>
+  </xsl:template>
+
+  <xsl:template match="ltx:text">
+    <xsl:choose>
+      <xsl:when test="contains(@font, 'bold')">
+        <hi rend="bold"><xsl:apply-templates/></hi>
+      </xsl:when>
+      <xsl:when test="contains(@font, 'italic') or contains(@font, 'slanted')">
+        <hi rend="italic"><xsl:apply-templates/></hi>
+      </xsl:when>
+      <xsl:when test="contains(@font, 'typewriter')">
+        <hi rend="monospace"><xsl:apply-templates/></hi>
+      </xsl:when>
+      <xsl:when test="contains(@font, 'smallcaps')">
+        <hi rend="smallcaps"><xsl:apply-templates/></hi>
+      </xsl:when>
+      <xsl:otherwise>
+        <xsl:apply-templates/>
+      </xsl:otherwise>
+    </xsl:choose>
+  </xsl:template>
+
+  <xsl:template match="ltx:sup">
+    <hi rend="superscript"><xsl:apply-templates/></hi>
+  </xsl:template>
+
+  <xsl:template match="ltx:sub">
+    <hi rend="subscript"><xsl:apply-templates/></hi>
+  </xsl:template>
+
+  <xsl:template match="ltx:ref[@href]">
+    <ref target="{@href}">
+      <xsl:apply-templates/>
+      <xsl:if test="not(node())"><xsl:value-of select="@href"/></xsl:if>
+    </ref>
+  </xsl:template>
+
+  <xsl:template match="ltx:ref[@idref]">
+    <ref target="#{@idref}">
+      <xsl:apply-templates/>
+    </ref>
+  </xsl:template>
+
+  <xsl:template match="ltx:note[@role='footnote']">
+    <note place="foot">
+      <xsl:call-template name="id"/>
+      <xsl:apply-templates select="node()[not(self::ltx:tag or self::ltx:tags)]"/>
+    </note>
+  </xsl:template>
+
+  <xsl:template match="ltx:break">
+    <lb/>
+  </xsl:template>
+
+  <!-- Bibliography -->
+
+  <xsl:template match="ltx:bibliography">
+    <div type="bibliography">
+      <xsl:if test="ltx:title">
+        <head><xsl:apply-templates select="ltx:title/node()"/></head>
+      </xsl:if>
+      <listBibl>
+        <xsl:apply-templates select=".//ltx:bibitem"/>
+      </listBibl>
+    </div>
+  </xsl:template>
+
+  <xsl:template match="ltx:bibitem">
+    <bibl>
+      <xsl:call-template name="id"/>
+      <xsl:call-template name="number"/>
+      <xsl:for-each select="ltx:bibblock">
+        <xsl:if test="position() > 1"><xsl:text> </xsl:text></xsl:if>
+        <xsl:apply-templates/>
+      </xsl:for-each>
+    </bibl>
+  </xsl:template>
+
+  <!-- Shared -->
+
+  <xsl:template name="id">
+    <xsl:if test="@xml:id">
+      <xsl:attribute name="xml:id"><xsl:value-of select="@xml:id"/></xsl:attribute>
+    </xsl:if>
+  </xsl:template>
+
+  <!-- number writes the number LaTeXML gave the element as its n attribute. -->
+  <xsl:template name="number">
+    <xsl:variable name="tag" select="(ltx:tags/ltx:tag[not(@role)] | ltx:tag | ltx:title/ltx:tag
+        | ltx:caption/ltx:tag)[1]"/>
+    <xsl:if test="$tag">
+      <xsl:attribute name="n"><xsl:value-of select="normalize-space($tag)"/></xsl:attribute>
+    </xsl:if>
+  </xsl:template>
+
+  <!-- Numbers are written as n attributes, and what TEI has no place for is
+       left out; any other element keeps its content only. -->
+  <xsl:template match="ltx:tag | ltx:tags | ltx:TOC | ltx:resource | ltx:navigation | ltx:XMath
+      | ltx:indexmark | ltx:ERROR"/>
+
+  <xsl:template match="ltx:*">
+    <xsl:apply-templates/>
+  </xsl:template>
+
+</xsl:stylesheet>
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+
+	"latex-renderer/internal/doctemplate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Template actions are delimited by << and >>, since {{ and }} are common
+// in TeX.
+const (
+	templateLeftDelim  = "<<"
+	templateRightDelim = ">>"
+)
+
+var docTemplates *doctemplate.Store
+
+// LoadTemplates opens the template registry at dir.
+func LoadTemplates(dir string) error {
+	store, err := doctemplate.Open(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range store.Names() {
+		source, _ := store.Get(name)
+		if _, err := parseDocTemplate(name, source); err != nil {
+			return fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+	docTemplates = store
+	return nil
+}
+
+// TemplateList lists the templates of the registry.
+type TemplateList struct {
+	Templates []string `json:"templates" example:"invoice,certificate"`
+}
+
+// TemplateInfo describes a stored template.
+type TemplateInfo struct {
+	Name string `json:"name" example:"invoice"`
+}
+
+// TemplateRenderReq is the body of a template render.
+type TemplateRenderReq struct {
+	// Data is what the template's actions read, as in <<.client.name>>.
+	Data map[string]any `json:"data" swaggertype:"object"`
+	// Format is the output format, as in /v1/render/{format}.
+	Format      string                `json:"format,omitempty" enums:"html,pdf,svg,png,jpeg,webp,thumbnail,epub,docx,odt,jats,tei" example:"pdf"`
+	Images      map[string]ImageInput `json:"images,omitempty"`
+	Options     RenderOptions         `json:"options"`
+	Metadata    *PDFMetadata          `json:"metadata,omitempty"`
+	PostProcess *PostProcessing       `json:"post_process,omitempty"`
+}
+
+// rawTeX is template output that is already TeX and is not escaped.
+type rawTeX string
+
+var docTemplateFuncs = template.FuncMap{
+	"tex": texValue,
+	"raw": func(s string) rawTeX { return rawTeX(s) },
+}
+
+// texValue is appended to every output action of a template: it escapes the
+// action's value for TeX, except for raw TeX.
+func texValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case rawTeX:
+		return string(v)
+	case float64:
+		// JSON numbers; %v would print large ones in exponent form.
+		return texEscape(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return texEscape(fmt.Sprint(v))
+	}
+}
+
+// parseDocTemplate parses a document template, a text/template with << >>
+// delimiters whose output actions are all escaped for TeX.
+func parseDocTemplate(name, source string) (*template.Template, error) {
+	t, err := template.New(name).Delims(templateLeftDelim, templateRightDelim).Funcs(docTemplateFuncs).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, defined := range t.Templates() {
+		if defined.Tree != nil {
+			escapeActions(defined.Tree.Root)
+		}
+	}
+	return t, nil
+}
+
+// escapeActions pipes the value of every action under node that writes
+// output into tex, as html/template does with its escapers. Actions that
+// only declare or assign variables write nothing and are left alone.
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier("tex").SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
+
+// fillTemplate executes the template name with data.
+func fillTemplate(name string, data map[string]any) (string, error) {
+	source, err := docTemplates.Get(name)
+	if err != nil {
+		return "", &apiError{status: http.StatusNotFound, msg: err.Error()}
+	}
+	t, err := parseDocTemplate(name, source)
+	if err != nil {
+		return "", internalError("cannot parse template")
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", &apiError{status: http.StatusBadRequest, msg: "template execution failed", detail: err.Error()}
+	}
+	return buf.String(), nil
+}
+
+// ListTemplates lists the registered templates.
+//
+//	@Summary		List document templates
+//	@Description	Returns the names of the templates in the registry: those loaded from TEMPLATES_DIR at startup and those uploaded through the admin API.
+//	@Tags			templates
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Bearer API key"
+//	@Success		200	{object}	TemplateList
+//	@Failure		401	{object}	ErrorResponse
+//	@Router			/v1/templates [get]
+func ListTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, TemplateList{Templates: docTemplates.Names()})
+}
+
+// PutTemplate uploads a template to the registry.
+//
+//	@Summary		Upload a document template
+//	@Description	Stores the body as the template name, replacing any template of that name. Templates are LaTeX documents with text/template actions between << and >>: <<.field>> inserts a value escaped for TeX, <<range .items>>…<<end>> loops, <<if .paid>>…<<else>>…<<end>> branches, and <<raw .field>> inserts trusted TeX unescaped. The template is parsed before it is stored. Needs the admin key.
+//	@Tags			templates
+//	@Accept			plain,application/x-tex
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Bearer admin API key"
+//	@Param			name			path		string	true	"Template name: lowercase letters, digits, - and _"
+//	@Param			template		body		string	true	"Template source"
+//	@Success		200	{object}	TemplateInfo	"Template replaced"
+//	@Success		201	{object}	TemplateInfo	"Template created"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/v1/admin/templates/{name} [put]
+func PutTemplate(c *gin.Context) {
+	name := c.Param("name")
+	if !doctemplate.ValidName(name) {
+		abortWithError(c, badRequest("invalid template name: use up to 64 lowercase letters, digits, - and _"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abortWithError(c, readBodyError(err))
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		abortWithError(c, badRequest("empty body"))
+		return
+	}
+	if _, err := parseDocTemplate(name, string(body)); err != nil {
+		abortWithError(c, &apiError{status: http.StatusBadRequest, msg: "invalid template", detail: err.Error()})
+		return
+	}
+
+	created, err := docTemplates.Put(name, string(body))
+	if err != nil {
+		abortWithError(c, internalError("cannot store template"))
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, TemplateInfo{Name: name})
+}
+
+// DeleteTemplate removes a template from the registry.
+//
+//	@Summary		Delete a document template
+//	@Description	Removes the template name from the registry and from TEMPLATES_DIR. Needs the admin key.
+//	@Tags			templates
+//	@Param			Authorization	header		string	true	"Bearer admin API key"
+//	@Param			name			path		string	true	"Template name"
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/v1/admin/templates/{name} [delete]
+func DeleteTemplate(c *gin.Context) {
+	err := docTemplates.Delete(c.Param("name"))
+	switch {
+	case errors.Is(err, doctemplate.ErrNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case err != nil:
+		abortWithError(c, internalError("cannot delete template"))
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RenderTemplate fills a template with data and renders the result.
+//
+//	@Summary		Render a document template
+//	@Description	Fills the template name with data, escaping every inserted value for TeX (& % $ # _ { } ~ ^ \), and renders the resulting document to format, pdf by default, with the same options, metadata and post-processing as /v1/render/{format}. Options may also be passed as query parameters. Alias of /v1/templates/{name}/render.
+//	@Tags			templates
+//	@Accept			json
+//	@Produce		application/pdf,text/html,image/svg+xml,image/png,image/jpeg,image/webp,application/zip,application/epub+zip,json
+//	@Param			Authorization	header		string				true	"Bearer API key"
+//	@Param			name			path		string				true	"Template name"
+//	@Param			request			body		TemplateRenderReq	true	"Data, output format and options"
+//	@Success		200	{file}		binary	"Rendered document"
+//	@Failure		400	{object}	ErrorResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		413	{object}	ErrorResponse
+//	@Failure		415	{object}	ErrorResponse
+//	@Failure		422	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Failure		501	{object}	ErrorResponse
+//	@Failure		504	{object}	ErrorResponse
+//	@Router			/templates/{name}/render [post]
+func RenderTemplate(c *gin.Context) {
+	req, format, err := newTemplateRenderReqFromContext(c)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	out, contentType, err := documentRenderers[format](c.Request.Context(), req)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, out)
+}
+
+// newTemplateRenderReqFromContext reads a template render and fills the
+// template, returning the render request for the document and its format.
+func newTemplateRenderReqFromContext(c *gin.Context) (*RenderReq, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "application/json" {
+		return nil, "", &apiError{status: http.StatusUnsupportedMediaType, msg: "unsupported content type: use application/json"}
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, "", readBodyError(err)
+	}
+
+	var treq TemplateRenderReq
+	if err := c.ShouldBindQuery(&treq.Options); err != nil {
+		return nil, "", badRequest("invalid options query")
+	}
+	if len(body) > 0 {
+		if err := decodeStrict(body, &treq); err != nil {
+			return nil, "", badRequest("invalid json body: " + err.Error())
+		}
+	}
+	if treq.Format == "" {
+		treq.Format = "pdf"
+	}
+	if _, ok := documentRenderers[treq.Format]; !ok {
+		return nil, "", badRequest("unknown format: " + treq.Format)
+	}
+	if treq.Options.InputFormat != "" && treq.Options.InputFormat != "latex" {
+		return nil, "", badRequest("templates are LaTeX: input_format must be latex")
+	}
+
+	content, err := fillTemplate(c.Param("name"), treq.Data)
+	if err != nil {
+		return nil, "", err
+	}
+	req := &RenderReq{
+		Content:     content,
+		Images:      treq.Images,
+		Options:     treq.Options,
+		Metadata:    treq.Metadata,
+		PostProcess: treq.PostProcess,
+		baseURL:     requestBaseURL(c),
+	}
+	if err := req.validate(); err != nil {
+		return nil, "", err
+	}
+	return req, treq.Format, nil
+}
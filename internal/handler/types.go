@@ -5,3 +5,123 @@ type ErrorResponse struct {
 	Error  string `json:"error" example:"latex render failed"`
 	Detail string `json:"detail,omitempty" example:"Undefined control sequence"`
 }
+
+// PageLink points to one rendered page kept in the artifact store.
+type PageLink struct {
+	Page        int    `json:"page" example:"1"`
+	URL         string `json:"url" example:"https://latex.example.com/artifacts/3f1c2a4e-8b7d-4e59-9a61-0c2d5e8f7b10"`
+	ContentType string `json:"content_type" example:"image/png"`
+}
+
+// PageLinks is the response of a multi-page render with bundle=links.
+type PageLinks struct {
+	Pages []PageLink `json:"pages"`
+}
+
+// PDFReport is the response of a PDF render with response=report.
+type PDFReport struct {
+	// PDF is the base64-encoded document.
+	PDF string `json:"pdf"`
+	// Metadata is the document information read back from the PDF.
+	Metadata  *DocumentInfo `json:"metadata"`
+	Pages     int           `json:"pages" example:"1"`
+	PageSizes []PageSize    `json:"page_sizes"`
+	Thumbnail *Thumbnail    `json:"thumbnail,omitempty"`
+	// PDFA is the conformance check of a pdfa render.
+	PDFA *PDFAReport `json:"pdfa,omitempty"`
+}
+
+// PageSize is the size of one page, in points.
+type PageSize struct {
+	Width  float64 `json:"width" example:"612"`
+	Height float64 `json:"height" example:"792"`
+}
+
+// Thumbnail is a small preview of the first page.
+type Thumbnail struct {
+	ContentType string `json:"content_type" example:"image/png"`
+	// Data is the base64-encoded image.
+	Data string `json:"data"`
+}
+
+// PDFAReport is the outcome of validating a PDF against a PDF/A level.
+type PDFAReport struct {
+	Level string `json:"level" example:"2b"`
+	// Validator is veraPDF when it is installed, or the built-in structural check.
+	Validator string `json:"validator" enums:"verapdf,builtin" example:"builtin"`
+	Compliant bool   `json:"compliant"`
+	// Diagnostics lists the conformance failures found.
+	Diagnostics []string `json:"diagnostics,omitempty" example:"font not embedded: Helvetica"`
+}
+
+// XMLReport is the response of a JATS or TEI render with response=report.
+type XMLReport struct {
+	Format string `json:"format" enums:"jats,tei" example:"jats"`
+	// XML is the document.
+	XML        string        `json:"xml"`
+	Validation XMLValidation `json:"validation"`
+}
+
+// XMLValidation is the outcome of validating XML output against its schema.
+type XMLValidation struct {
+	// Schema is the JATS DTD or the TEI RelaxNG schema checked against.
+	Schema string `json:"schema" example:"JATS-archivearticle1-3-mathml3.dtd"`
+	// Validated is false when the schema is not installed, in which case
+	// Valid says nothing about the document.
+	Validated bool `json:"validated"`
+	Valid     bool `json:"valid"`
+	// Diagnostics lists the validity errors found, by line of the XML.
+	Diagnostics []string `json:"diagnostics,omitempty" example:"line 12: Element sec content does not follow the DTD"`
+}
+
+// OfficeReport is the response of a DOCX or ODT render with response=report.
+type OfficeReport struct {
+	Format      string `json:"format" enums:"docx,odt" example:"docx"`
+	ContentType string `json:"content_type" example:"application/vnd.openxmlformats-officedocument.wordprocessingml.document"`
+	// Document is the base64-encoded file.
+	Document string `json:"document"`
+	// Warnings lists the constructs pandoc could not convert or skipped.
+	Warnings []string `json:"warnings" example:"Skipped '\\vspace{1cm}' at input line 5 column 1"`
+}
+
+// Extraction is the text and outline of a document, from /extract.
+type Extraction struct {
+	Title   string   `json:"title,omitempty" example:"Heat flow"`
+	Authors []string `json:"authors,omitempty" example:"Ada Lovelace"`
+	// Text is the plain text of the whole document, with blank lines
+	// between paragraphs and math written as math_text says.
+	Text    string  `json:"text" example:"Heat flow\n\n1 Introduction\n\nThe heat equation u_t = \\Delta u ..."`
+	Outline Outline `json:"outline"`
+}
+
+// Outline lists the structure of a document in reading order.
+type Outline struct {
+	Sections  []OutlineSection  `json:"sections"`
+	Figures   []OutlineFloat    `json:"figures"`
+	Tables    []OutlineFloat    `json:"tables"`
+	Equations []OutlineEquation `json:"equations"`
+}
+
+// OutlineSection is a chapter, section or other sectioning unit.
+type OutlineSection struct {
+	Level  string   `json:"level" example:"section"`
+	Number string   `json:"number,omitempty" example:"2.1"`
+	Title  string   `json:"title" example:"Results"`
+	Labels []string `json:"labels,omitempty" example:"sec:results"`
+}
+
+// OutlineFloat is a figure or table and its caption.
+type OutlineFloat struct {
+	Number  string   `json:"number,omitempty" example:"3"`
+	Caption string   `json:"caption" example:"Temperature over time."`
+	Labels  []string `json:"labels,omitempty" example:"fig:temp"`
+}
+
+// OutlineEquation is a display equation, or a row of an alignment.
+type OutlineEquation struct {
+	Number string `json:"number,omitempty" example:"1"`
+	TeX    string `json:"tex" example:"u_t = \\Delta u"`
+	// Text is LaTeXML's linear alt text of the formula, if it parsed.
+	Text   string   `json:"text,omitempty" example:"u_t = Delta u"`
+	Labels []string `json:"labels,omitempty" example:"eq:heat"`
+}
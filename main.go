@@ -27,13 +27,59 @@ func main() {
 		panic("API_KEY env var is required")
 	}
 
+	templatesDir := os.Getenv("TEMPLATES_DIR")
+	if templatesDir == "" {
+		templatesDir = "templates"
+	}
+	if err := handler.LoadTemplates(templatesDir); err != nil {
+		panic("cannot load templates: " + err.Error())
+	}
+	go handler.StartMathWorkers()
+
 	r := gin.Default()
 	r.Use(middleware.CORS())
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Artifact ids are unguessable, so links work without the API key.
+	r.GET("/artifacts/:id", handler.GetArtifact)
+	r.GET("/assets/latexml.css", handler.GetStylesheet)
+
+	v1 := r.Group("/v1", middleware.BearerAuth(apiKey))
+	v1.POST("/render/:format", handler.RenderFormat)
+	v1.POST("/render/math", handler.RenderMath)
+	v1.POST("/render/batch", handler.RenderBatch)
+	v1.POST("/render/merge", handler.RenderMerge)
+	v1.POST("/extract", handler.Extract)
+	v1.GET("/templates", handler.ListTemplates)
+	v1.POST("/templates/:name/render", handler.RenderTemplate)
+
+	// Templates can only be changed with the admin key, and not at all
+	// without one.
+	if adminKey := os.Getenv("ADMIN_API_KEY"); adminKey != "" {
+		admin := r.Group("/v1/admin", middleware.BearerAuth(adminKey))
+		admin.PUT("/templates/:name", handler.PutTemplate)
+		admin.DELETE("/templates/:name", handler.DeleteTemplate)
+	}
+
+	// Unversioned aliases of the /v1 routes.
 	r.POST("/render", middleware.BearerAuth(apiKey), handler.Render)
 	r.POST("/render/pdf", middleware.BearerAuth(apiKey), handler.RenderPDF)
+	r.POST("/render/svg", middleware.BearerAuth(apiKey), handler.RenderSVG)
+	r.POST("/render/png", middleware.BearerAuth(apiKey), handler.RenderImage("png"))
+	r.POST("/render/jpeg", middleware.BearerAuth(apiKey), handler.RenderImage("jpeg"))
+	r.POST("/render/webp", middleware.BearerAuth(apiKey), handler.RenderImage("webp"))
+	r.POST("/render/thumbnail", middleware.BearerAuth(apiKey), handler.RenderThumbnail)
+	r.POST("/render/epub", middleware.BearerAuth(apiKey), handler.RenderEPUB)
+	r.POST("/render/docx", middleware.BearerAuth(apiKey), handler.RenderOffice("docx"))
+	r.POST("/render/odt", middleware.BearerAuth(apiKey), handler.RenderOffice("odt"))
+	r.POST("/render/jats", middleware.BearerAuth(apiKey), handler.RenderXML("jats"))
+	r.POST("/render/tei", middleware.BearerAuth(apiKey), handler.RenderXML("tei"))
+	r.POST("/render/math", middleware.BearerAuth(apiKey), handler.RenderMath)
+	r.POST("/render/batch", middleware.BearerAuth(apiKey), handler.RenderBatch)
+	r.POST("/render/merge", middleware.BearerAuth(apiKey), handler.RenderMerge)
+	r.POST("/templates/:name/render", middleware.BearerAuth(apiKey), handler.RenderTemplate)
+	r.POST("/extract", middleware.BearerAuth(apiKey), handler.Extract)
 
 	r.Run(":8080")
 }
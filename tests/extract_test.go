@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	content := `\documentclass{article}\title{Heat flow}\author{Ada Lovelace}\begin{document}\maketitle` +
+		`\section{Introduction}\label{sec:intro}The heat equation is` +
+		`\begin{equation}\label{eq:heat}u_t = \Delta u\end{equation}` +
+		`with $u(0) = f$.\begin{figure}\caption{Temperature over time.}\label{fig:temp}\end{figure}\end{document}`
+	body, err := json.Marshal(map[string]any{"content": content})
+	require.NoError(t, err)
+	resp := postJSON(t, "/extract", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+
+	var result struct {
+		Title   string   `json:"title"`
+		Authors []string `json:"authors"`
+		Text    string   `json:"text"`
+		Outline struct {
+			Sections []struct {
+				Level  string   `json:"level"`
+				Number string   `json:"number"`
+				Title  string   `json:"title"`
+				Labels []string `json:"labels"`
+			} `json:"sections"`
+			Figures []struct {
+				Caption string   `json:"caption"`
+				Labels  []string `json:"labels"`
+			} `json:"figures"`
+			Equations []struct {
+				Number string   `json:"number"`
+				TeX    string   `json:"tex"`
+				Labels []string `json:"labels"`
+			} `json:"equations"`
+		} `json:"outline"`
+	}
+	require.NoError(t, json.Unmarshal(out, &result))
+	assert.Equal(t, "Heat flow", result.Title)
+	assert.Equal(t, []string{"Ada Lovelace"}, result.Authors)
+	assert.Contains(t, result.Text, "The heat equation is")
+	assert.Contains(t, result.Text, "u(0)")
+	assert.NotContains(t, result.Text, "<")
+
+	require.Len(t, result.Outline.Sections, 1)
+	assert.Equal(t, "section", result.Outline.Sections[0].Level)
+	assert.Equal(t, "1", result.Outline.Sections[0].Number)
+	assert.Equal(t, "Introduction", result.Outline.Sections[0].Title)
+	assert.Equal(t, []string{"sec:intro"}, result.Outline.Sections[0].Labels)
+
+	require.Len(t, result.Outline.Figures, 1)
+	assert.Equal(t, "Temperature over time.", result.Outline.Figures[0].Caption)
+	assert.Equal(t, []string{"fig:temp"}, result.Outline.Figures[0].Labels)
+
+	require.Len(t, result.Outline.Equations, 1)
+	assert.Equal(t, "1", result.Outline.Equations[0].Number)
+	assert.Contains(t, result.Outline.Equations[0].TeX, `\Delta`)
+	assert.Equal(t, []string{"eq:heat"}, result.Outline.Equations[0].Labels)
+}
+
+func TestExtract_InvalidMathText(t *testing.T) {
+	resp := postJSON(t, "/v1/extract", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"math_text": "mathml"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, `invalid math_text "mathml": must be one of tex, alt`, result["error"])
+}
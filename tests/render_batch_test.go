@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchResult struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        string `json:"body"`
+	Encoding    string `json:"encoding"`
+	Error       *struct {
+		Error  string `json:"error"`
+		Detail string `json:"detail"`
+	} `json:"error"`
+}
+
+func postJSON(t *testing.T, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("POST", baseURL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func readBatchResults(t *testing.T, resp *http.Response) []batchResult {
+	t.Helper()
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var result struct {
+		Results []batchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(body, &result), "body: %s", string(body))
+	return result.Results
+}
+
+func TestRenderBatch_MixedItemsKeepOrder(t *testing.T) {
+	resp := postJSON(t, "/render/batch", `{"items": [
+		{"type": "math", "math": {"tex": "x^2"}},
+		{"type": "math", "math": {"tex": "\\frac{1}{"}},
+		{"type": "document", "format": "pdf", "document": {"content": "\\documentclass{article}\\begin{document}Hi\\end{document}"}}
+	]}`)
+	results := readBatchResults(t, resp)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, "utf8", results[0].Encoding)
+	assert.Contains(t, results[0].Body, "<math")
+
+	assert.Equal(t, http.StatusBadRequest, results[1].Status)
+	require.NotNil(t, results[1].Error)
+
+	assert.Equal(t, http.StatusOK, results[2].Status)
+	assert.Equal(t, "application/pdf", results[2].ContentType)
+	assert.Equal(t, "base64", results[2].Encoding)
+}
+
+func TestRenderBatch_InvalidItemDoesNotFailBatch(t *testing.T) {
+	resp := postJSON(t, "/render/batch", `{"items": [
+		{"type": "bogus"},
+		{"type": "document", "format": "gif", "document": {"content": "x"}}
+	]}`)
+	results := readBatchResults(t, resp)
+	require.Len(t, results, 2)
+
+	for _, r := range results {
+		assert.Equal(t, http.StatusBadRequest, r.Status)
+		require.NotNil(t, r.Error)
+		assert.NotEmpty(t, r.Error.Error)
+	}
+}
+
+func TestRenderBatch_DOCX(t *testing.T) {
+	resp := postJSON(t, "/render/batch", `{"items": [
+		{"type": "document", "format": "docx", "document": {"content": "\\documentclass{article}\\begin{document}Hi\\end{document}"}}
+	]}`)
+	results := readBatchResults(t, resp)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", results[0].ContentType)
+	assert.Equal(t, "base64", results[0].Encoding)
+	assert.NotEmpty(t, results[0].Body)
+}
+
+func TestRenderBatch_EmptyItems(t *testing.T) {
+	resp := postJSON(t, "/render/batch", `{"items": []}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "items is required", result["error"])
+}
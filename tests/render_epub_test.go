@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderEPUB(t *testing.T) {
+	content := `\documentclass{article}\title{Lecture Notes}\author{Grace Hopper}\begin{document}\maketitle` +
+		`\section{Sets}A set $A \subseteq B$.\section{Maps}A map $f\colon A \to B$.\end{document}`
+	body, err := json.Marshal(map[string]any{"content": content})
+	require.NoError(t, err)
+	resp := postJSON(t, "/render/epub", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+	assert.Equal(t, "application/epub+zip", resp.Header.Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	require.NotEmpty(t, zr.File)
+	assert.Equal(t, "mimetype", zr.File[0].Name)
+	assert.Equal(t, zip.Store, zr.File[0].Method)
+
+	var opf, pages string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		switch {
+		case strings.HasSuffix(f.Name, ".opf"):
+			opf = string(data)
+		case strings.HasSuffix(f.Name, ".xhtml"):
+			pages += string(data)
+		}
+	}
+	assert.Contains(t, opf, "Lecture Notes")
+	assert.Contains(t, opf, "Grace Hopper")
+	assert.Contains(t, opf, `properties="nav"`)
+	assert.Contains(t, pages, "<math")
+}
@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderHTML posts a document with options to /render and returns the HTML.
+func renderHTML(t *testing.T, content string, options map[string]any) string {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"content": content, "options": options})
+	require.NoError(t, err)
+	resp := postJSON(t, "/render", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+	return string(out)
+}
+
+func TestRenderHTML_SanitizeXSSPayloads(t *testing.T) {
+	payloads := map[string]string{
+		"href javascript":   `\href{javascript:alert(1)}{click}`,
+		"href mixed case":   `\href{JaVaScRiPt:alert(1)}{click}`,
+		"href tab":          "\\href{java\tscript:alert(1)}{click}",
+		"url javascript":    `\url{javascript:alert(document.cookie)}`,
+		"href vbscript":     `\href{vbscript:msgbox(1)}{click}`,
+		"href data html":    `\href{data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==}{click}`,
+		"rawhtml script":    `\begin{rawhtml}<script>alert(1)</script>\end{rawhtml}`,
+		"rawhtml onerror":   `\begin{rawhtml}<img src="x" onerror="alert(1)">\end{rawhtml}`,
+		"rawhtml iframe":    `\begin{rawhtml}<iframe src="javascript:alert(1)"></iframe>\end{rawhtml}`,
+		"rawhtml svg":       `\begin{rawhtml}<svg><a xlink:href="javascript:alert(1)"><set attributeName="href" to="javascript:alert(1)"/>x</a></svg>\end{rawhtml}`,
+		"rawhtml math href": `\begin{rawhtml}<math><mi href="javascript:alert(1)">x</mi></math>\end{rawhtml}`,
+		"text script":       `\textless script\textgreater alert(1)\textless /script\textgreater`,
+	}
+	for name, payload := range payloads {
+		t.Run(name, func(t *testing.T) {
+			content := "\\documentclass{article}\\usepackage{html}\\usepackage{hyperref}\\begin{document}" + payload + "\\end{document}"
+			out := strings.ToLower(renderHTML(t, content, map[string]any{"sanitize": true, "css": "none"}))
+			for _, bad := range []string{"<script", "onerror", "<iframe", "<set"} {
+				assert.NotContains(t, out, bad)
+			}
+			// The URL may remain as link text, but never as an attribute.
+			assert.NotRegexp(t, `="[^"]*(script|data:text/html)`, out)
+		})
+	}
+}
+
+func TestRenderHTML_SanitizeStripIDs(t *testing.T) {
+	content := `\documentclass{article}\begin{document}\section{Intro}\label{sec:intro}See \ref{sec:intro}.\end{document}`
+	kept := renderHTML(t, content, map[string]any{"sanitize": true})
+	assert.Contains(t, kept, ` id="`)
+
+	stripped := renderHTML(t, content, map[string]any{"sanitize": true, "strip_ids": true})
+	assert.NotContains(t, stripped, ` id="`)
+	assert.Contains(t, stripped, "Intro")
+}
+
+func TestRenderHTML_StripIDsNeedsSanitize(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"strip_ids": true}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "strip_ids requires sanitize", result["error"])
+}
+
+func TestRenderHTML_InvalidTheme(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"theme": "neon"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], `invalid theme "neon"`)
+}
+
+func TestRenderHTML_InvalidMathFormat(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}$x$\\end{document}", "options": {"math_format": "tex+pmml"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], `invalid math_format "tex+pmml"`)
+}
+
+func TestRenderHTML_InvalidHTMLImages(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"html_images": "tar"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], `invalid html_images "tar"`)
+}
+
+func TestRenderHTML_SplitImagesZipNeedsZipBundle(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"split": "section", "bundle": "links", "html_images": "zip"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "html_images=zip requires bundle=zip with split", result["error"])
+}
+
+func TestRenderHTML_SplitManifest(t *testing.T) {
+	content := `\documentclass{article}\title{Thesis}\begin{document}\maketitle\section{One}\label{s:one}See Section~\ref{s:two}.\section{Two}\label{s:two}Back to \ref{s:one}.\end{document}`
+	body, err := json.Marshal(map[string]any{"content": content, "options": map[string]any{"split": "section", "bundle": "links"}})
+	require.NoError(t, err)
+	resp := postJSON(t, "/render", string(body))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var manifest struct {
+		Pages []struct {
+			Order   int      `json:"order"`
+			Title   string   `json:"title"`
+			URL     string   `json:"url"`
+			Anchors []string `json:"anchors"`
+			Next    string   `json:"next"`
+		} `json:"pages"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&manifest))
+	require.Len(t, manifest.Pages, 3)
+	assert.Contains(t, manifest.Pages[1].Title, "One")
+	assert.Contains(t, manifest.Pages[2].Title, "Two")
+	assert.Equal(t, 3, manifest.Pages[2].Order)
+
+	assert.True(t, strings.HasPrefix(manifest.Pages[1].URL, baseURL+"/artifacts/"), "url: %s", manifest.Pages[1].URL)
+	page, err := http.Get(manifest.Pages[1].URL)
+	require.NoError(t, err)
+	defer page.Body.Close()
+	html, err := io.ReadAll(page.Body)
+	require.NoError(t, err)
+	// Pages link to each other by path, as they are served from one origin.
+	assert.Contains(t, string(html), `href="`+strings.TrimPrefix(manifest.Pages[2].URL, baseURL)+`#`)
+}
+
+func TestRenderHTML_CSSScopeNeedsFragment(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"css_scope": "shadow", "html_mode": "document"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "css_scope requires html_mode=fragment", result["error"])
+}
+
+func TestStylesheet_ScopedClass(t *testing.T) {
+	resp, err := http.Get(baseURL + "/assets/latexml.css?scope=class")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasSuffix(line, "}") && strings.Contains(line, "{") && !strings.HasPrefix(strings.TrimSpace(line), "@") {
+			assert.True(t, strings.HasPrefix(strings.TrimSpace(line), ".ltx-scope-"), "unscoped rule: %s", line)
+		}
+	}
+}
+
+func TestStylesheet_ThemeIsCached(t *testing.T) {
+	resp, err := http.Get(baseURL + "/assets/latexml.css?theme=dark")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/css")
+	assert.Contains(t, string(body), ".ltx_document")
+
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	version, _, _ := strings.Cut(strings.Trim(etag, `"`), "-")
+
+	req, err := http.NewRequest("GET", baseURL+"/assets/latexml.css?theme=dark&v="+version, nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "immutable")
+}
+
+func TestStylesheet_UnknownTheme(t *testing.T) {
+	resp, err := http.Get(baseURL + "/assets/latexml.css?theme=neon")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestRenderHTML_CSSLinkIsAbsolute(t *testing.T) {
+	html := renderHTML(t, `\documentclass{article}\begin{document}Hi\end{document}`, map[string]any{"css": "link"})
+	assert.Contains(t, html, `href="`+baseURL+`/assets/latexml.css?v=`)
+}
+
+const spanishDoc = `\documentclass{article}
+\usepackage[english,spanish]{babel}
+\title{Informe \emph{anual}}
+\begin{document}
+\maketitle
+Hola.
+\end{document}`
+
+func TestRenderHTML_DocumentMode(t *testing.T) {
+	html := renderHTML(t, spanishDoc, map[string]any{"html_mode": "document"})
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"), "missing doctype")
+	assert.Regexp(t, `<html[^>]* lang="es"`, html)
+	assert.Contains(t, html, "<title>Informe anual</title>")
+	assert.Contains(t, html, `<meta charset="utf-8">`)
+	assert.Contains(t, html, "Hola.")
+}
+
+func TestRenderHTML_DocumentModeLangAndTitle(t *testing.T) {
+	body, err := json.Marshal(map[string]any{
+		"content":  spanishDoc,
+		"options":  map[string]any{"html_mode": "document", "lang": "pt-BR"},
+		"metadata": map[string]any{"title": "Relatório <2024>", "author": "Ana"},
+	})
+	require.NoError(t, err)
+	resp := postJSON(t, "/render", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+
+	html := string(out)
+	assert.Regexp(t, `<html[^>]* lang="pt-BR"`, html)
+	assert.NotContains(t, html, `lang="es"`)
+	assert.Contains(t, html, "<title>Relatório &lt;2024&gt;</title>")
+	assert.Equal(t, 1, strings.Count(html, "<title>"))
+	assert.Contains(t, html, `<meta name="author" content="Ana">`)
+}
+
+func TestRenderHTML_FragmentModeUnchanged(t *testing.T) {
+	for _, options := range []map[string]any{nil, {"html_mode": "fragment"}} {
+		html := renderHTML(t, spanishDoc, options)
+		assert.NotContains(t, html, "<!DOCTYPE")
+		assert.NotContains(t, html, "<html")
+		assert.NotContains(t, html, "<head")
+		assert.NotContains(t, html, "<title>")
+		assert.Contains(t, html, "Hola.")
+	}
+}
+
+func TestRenderHTML_InvalidLang(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"html_mode": "document", "lang": "not a tag"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, `invalid lang "not a tag": use a language tag such as es or pt-BR`, result["error"])
+}
+
+func TestRenderHTML_ImageLinksAreAbsolute(t *testing.T) {
+	content := `\documentclass{article}\begin{document}Euler: $e^{i\pi} + 1 = 0$\end{document}`
+	html := renderHTML(t, content, map[string]any{"math_format": "svg", "html_images": "links"})
+
+	m := regexp.MustCompile(`<img[^>]*\ssrc="([^"]*)"`).FindStringSubmatch(html)
+	require.NotNil(t, m, "no image in %s", html)
+	require.True(t, strings.HasPrefix(m[1], baseURL+"/artifacts/"), "src: %s", m[1])
+
+	resp := getArtifact(t, m[1])
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const twoPageDoc = `\documentclass{article}
+\begin{document}
+First page.
+\newpage
+Second page.
+\end{document}`
+
+// postDocument posts a document with options as JSON to path.
+func postDocument(t *testing.T, path, content string, options map[string]any) *http.Response {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"content": content, "options": options})
+	require.NoError(t, err)
+	return postJSON(t, path, string(body))
+}
+
+// getArtifact fetches an artifact link without the API key, as a browser
+// following it would.
+func getArtifact(t *testing.T, url string) *http.Response {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	return resp
+}
+
+type pageLinks struct {
+	Pages []struct {
+		Page        int    `json:"page"`
+		URL         string `json:"url"`
+		ContentType string `json:"content_type"`
+	} `json:"pages"`
+}
+
+func TestRenderImage_Formats(t *testing.T) {
+	formats := map[string]struct {
+		contentType string
+		magic       string
+	}{
+		"png":  {"image/png", "\x89PNG"},
+		"jpeg": {"image/jpeg", "\xff\xd8\xff"},
+		"webp": {"image/webp", "RIFF"},
+	}
+	for format, want := range formats {
+		t.Run(format, func(t *testing.T) {
+			resp := postDocument(t, "/render/"+format, twoPageDoc, map[string]any{"pages": "1", "dpi": 72})
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+
+			assert.Equal(t, want.contentType, resp.Header.Get("Content-Type"))
+			assert.True(t, strings.HasPrefix(string(body), want.magic), "missing %s magic bytes", format)
+		})
+	}
+}
+
+func TestRenderImage_MaxDimension(t *testing.T) {
+	resp := postDocument(t, "/render/png", twoPageDoc, map[string]any{"pages": "1", "dpi": 600, "max_dimension": 1000})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], "over max_dimension 1000")
+}
+
+func TestRenderImage_InvalidMaxDimension(t *testing.T) {
+	resp := postDocument(t, "/render/png", twoPageDoc, map[string]any{"max_dimension": 8})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "invalid max_dimension: must be between 16 and 10000", result["error"])
+}
+
+func TestRenderImage_LinksFetchArtifacts(t *testing.T) {
+	resp := postDocument(t, "/render/png", twoPageDoc, map[string]any{"dpi": 72, "bundle": "links"})
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+
+	var links pageLinks
+	require.NoError(t, json.Unmarshal(body, &links), "body: %s", string(body))
+	require.Len(t, links.Pages, 2)
+
+	for i, link := range links.Pages {
+		assert.Equal(t, i+1, link.Page)
+		assert.Equal(t, "image/png", link.ContentType)
+		require.True(t, strings.HasPrefix(link.URL, baseURL+"/artifacts/"), "url: %s", link.URL)
+
+		page := getArtifact(t, link.URL)
+		data, err := io.ReadAll(page.Body)
+		page.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, page.StatusCode)
+		assert.Equal(t, "image/png", page.Header.Get("Content-Type"))
+		assert.Equal(t, "nosniff", page.Header.Get("X-Content-Type-Options"))
+		assert.Contains(t, page.Header.Get("Cache-Control"), "max-age=")
+		assert.True(t, strings.HasPrefix(string(data), "\x89PNG"), "missing PNG magic bytes")
+	}
+}
+
+func TestArtifact_UnknownID(t *testing.T) {
+	for _, id := range []string{"3f1c2a4e-8b7d-4e59-9a61-0c2d5e8f7b10", "not-a-uuid"} {
+		resp := getArtifact(t, baseURL+"/artifacts/"+id)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode, "id %s", id)
+
+		result := readErrorResponse(t, resp)
+		assert.Equal(t, "artifact not found", result["error"])
+	}
+}
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown_HTML(t *testing.T) {
+	content := "---\ntitle: From front matter\n---\n\n# Kinetics\n\nThe rate is $k = A e^{-E_a/RT}$ and\n\n$$\\int_0^1 x\\,dx = \\tfrac12$$\n"
+	html := renderHTML(t, content, map[string]any{
+		"input_format": "markdown",
+		"title":        "Chemistry 101",
+		"author":       "Marie Curie",
+	})
+	assert.Contains(t, html, "Chemistry 101")
+	assert.NotContains(t, html, "From front matter")
+	assert.Contains(t, html, "Marie Curie")
+	assert.Contains(t, html, "Kinetics")
+	assert.Contains(t, html, "<math")
+}
+
+func TestRenderMarkdown_TemplateNeedsMarkdown(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"template": "beamer"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "template, title, author and date require input_format=markdown", result["error"])
+}
+
+func TestRenderMarkdown_InvalidTemplate(t *testing.T) {
+	resp := postJSON(t, "/render", `{"content": "# Hi", "options": {"input_format": "markdown", "template": "letter"}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], `invalid template "letter"`)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMath_MathML(t *testing.T) {
+	resp := postJSON(t, "/render/math", `{"tex": "\\R^n", "mode": "display", "macros": "\\newcommand{\\R}{\\mathbb{R}}"}`)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+
+	assert.Equal(t, "application/mathml+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	mathml := string(body)
+	assert.Contains(t, mathml, "<math")
+	assert.Contains(t, mathml, `display="block"`)
+	assert.Contains(t, mathml, "ℝ")
+}
+
+func TestRenderMath_ParseError(t *testing.T) {
+	resp := postJSON(t, "/render/math", `{"tex": "\\frac{1}{"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "math render failed", result["error"])
+	assert.NotEmpty(t, result["detail"])
+}
+
+// An expression that never finishes expanding times out, and the pool
+// replaces the killed worker for the next request.
+func TestRenderMath_Timeout(t *testing.T) {
+	resp := postJSON(t, "/render/math", `{"tex": "\\def\\loop{\\loop}\\loop"}`)
+	require.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "render timed out", result["error"])
+
+	resp = postJSON(t, "/render/math", `{"tex": "x^2"}`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
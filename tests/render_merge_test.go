@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mergeReport struct {
+	PDF       string `json:"pdf"`
+	Pages     int    `json:"pages"`
+	Documents []struct {
+		Title     string            `json:"title"`
+		Status    int               `json:"status"`
+		StartPage int               `json:"start_page"`
+		Pages     int               `json:"pages"`
+		Error     map[string]string `json:"error"`
+	} `json:"documents"`
+}
+
+func TestRenderMerge_EmptyDocuments(t *testing.T) {
+	resp := postJSON(t, "/render/merge", `{"documents": []}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "documents is required", result["error"])
+}
+
+func TestRenderMerge_ReportsPageRanges(t *testing.T) {
+	body := `{"response": "report", "page_numbers": {}, "documents": [
+		{"title": "First", "content": "\\documentclass{article}\\begin{document}One\\newpage Two\\end{document}"},
+		{"content": "\\documentclass{article}\\title{Second}\\begin{document}Three\\end{document}", "options": {"engine": "xelatex"}}
+	]}`
+	resp := postJSON(t, "/render/merge", body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report mergeReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Len(t, report.Documents, 2)
+	assert.NotEmpty(t, report.PDF)
+	assert.Equal(t, 3, report.Pages)
+	assert.Equal(t, "First", report.Documents[0].Title)
+	assert.Equal(t, 1, report.Documents[0].StartPage)
+	assert.Equal(t, "Second", report.Documents[1].Title)
+	assert.Equal(t, 3, report.Documents[1].StartPage)
+}
+
+func TestRenderMerge_FailureListsDocument(t *testing.T) {
+	body := `{"documents": [
+		{"title": "Good", "content": "\\documentclass{article}\\begin{document}Fine\\end{document}"},
+		{"title": "Broken", "content": ""}
+	]}`
+	resp := postJSON(t, "/render/merge", body)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "1 of 2 documents failed", result["error"])
+	assert.Contains(t, result["detail"], "Broken: content is required")
+}
+
+func TestRenderMerge_NonCompliantPDFAFails(t *testing.T) {
+	// The font map drops the font file, so Times is not embedded.
+	body := `{"documents": [
+		{"title": "Compliant", "content": "\\documentclass{article}\\begin{document}Fine\\end{document}", "options": {"pdfa": "2b"}},
+		{"title": "Unembedded", "content": "\\documentclass{article}\\usepackage{times}\\pdfmapline{=ptmr8r Times-Roman \"TeXBase1Encoding ReEncodeFont\" <8r.enc}\\begin{document}Times\\end{document}", "options": {"pdfa": "2b"}}
+	]}`
+	resp := postJSON(t, "/render/merge", body)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "1 of 2 documents failed", result["error"])
+	assert.Contains(t, result["detail"], "Unembedded: pdf/a-2b validation failed")
+}
+
+func TestRenderMerge_TitleIsPlainText(t *testing.T) {
+	// The document fails validation, so its bookmark title shows in the error.
+	body := `{"documents": [
+		{"content": "\\documentclass{article}\\title{A \\emph{Study}\\\\ of Things\\thanks{Funded by X.}}\\begin{document}\\maketitle\\end{document}", "options": {"engine": "tex"}}
+	]}`
+	resp := postJSON(t, "/render/merge", body)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.True(t, strings.HasPrefix(result["detail"], "A Study of Things: invalid engine"), "detail: %s", result["detail"])
+}
+
+// qpdf runs qpdf on the PDF in data, skipping the test where it is missing.
+func qpdf(t *testing.T, data []byte, args ...string) []byte {
+	t.Helper()
+	if _, err := exec.LookPath("qpdf"); err != nil {
+		t.Skip("qpdf is not installed")
+	}
+	file := filepath.Join(t.TempDir(), "merged.pdf")
+	require.NoError(t, os.WriteFile(file, data, 0600))
+	out, err := exec.Command("qpdf", append(args, file)...).CombinedOutput()
+	require.NoError(t, err, "qpdf: %s", string(out))
+	return out
+}
+
+func TestRenderMerge_Outline(t *testing.T) {
+	body := `{"response": "report", "documents": [
+		{"title": "First", "content": "\\documentclass{article}\\begin{document}One\\newpage Two\\end{document}"},
+		{"content": "\\documentclass{article}\\title{Second \\emph{Part}}\\begin{document}Three\\end{document}"},
+		{"title": "Third", "content": "\\documentclass{article}\\begin{document}Four\\end{document}"}
+	]}`
+	resp := postJSON(t, "/render/merge", body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report mergeReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Len(t, report.Documents, 3)
+	for _, doc := range report.Documents {
+		require.Equal(t, http.StatusOK, doc.Status, "%s: %v", doc.Title, doc.Error)
+	}
+	merged, err := base64.StdEncoding.DecodeString(report.PDF)
+	require.NoError(t, err)
+	require.NotEmpty(t, merged)
+
+	// The incremental update must leave a well-formed file.
+	qpdf(t, merged, "--check")
+
+	var outline struct {
+		Outlines []struct {
+			Title string `json:"title"`
+			Page  int    `json:"destpageposfrom1"`
+		} `json:"outlines"`
+	}
+	require.NoError(t, json.Unmarshal(qpdf(t, merged, "--json", "--json-key=outlines"), &outline))
+	require.Len(t, outline.Outlines, 3)
+	for i, want := range []struct {
+		title string
+		page  int
+	}{{"First", 1}, {"Second Part", 3}, {"Third", 4}} {
+		assert.Equal(t, want.title, outline.Outlines[i].Title)
+		assert.Equal(t, want.page, outline.Outlines[i].Page)
+		assert.Equal(t, want.page, report.Documents[i].StartPage)
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDOCX_Report(t *testing.T) {
+	content := `\documentclass{article}\title{Field notes}\begin{document}\maketitle` +
+		`\section{Energy}\vspace{1cm}The energy is $E = mc^2$.` +
+		`\begin{table}\begin{tabular}{ll}a & b\\c & d\end{tabular}\caption{Values}\end{table}\end{document}`
+	body, err := json.Marshal(map[string]any{"content": content, "options": map[string]any{"response": "report"}})
+	require.NoError(t, err)
+	resp := postJSON(t, "/render/docx", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+
+	var report struct {
+		Format   string   `json:"format"`
+		Document string   `json:"document"`
+		Warnings []string `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(out, &report))
+	assert.Equal(t, "docx", report.Format)
+	require.NotEmpty(t, report.Warnings)
+	assert.Contains(t, report.Warnings[0], "vspace")
+
+	docx, err := base64.StdEncoding.DecodeString(report.Document)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	require.NoError(t, err)
+	f, err := zr.Open("word/document.xml")
+	require.NoError(t, err)
+	document, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Contains(t, string(document), "<m:oMath")
+	assert.Contains(t, string(document), "<w:tbl>")
+	assert.Contains(t, string(document), "Field notes")
+}
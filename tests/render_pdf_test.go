@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -157,3 +159,194 @@ func TestRenderPDF_WrongAuth(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
 }
+
+func postRenderPDFWithType(t *testing.T, contentType, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("POST", baseURL+"/render/pdf", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestRenderPDF_JSONBody(t *testing.T) {
+	data, err := os.ReadFile("fixtures/simple.tex")
+	require.NoError(t, err)
+	body, err := json.Marshal(map[string]string{"content": string(data)})
+	require.NoError(t, err)
+
+	resp := postRenderPDFWithType(t, "application/json", string(body))
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	pdf, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "%PDF-", string(pdf[:5]), "missing PDF magic bytes")
+}
+
+func TestRenderPDF_JSONMissingContent(t *testing.T) {
+	resp := postRenderPDFWithType(t, "application/json", `{"images": {}}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "content is required", result["error"])
+}
+
+func TestRenderPDF_UnsupportedContentType(t *testing.T) {
+	resp := postRenderPDFWithType(t, "application/xml", "<doc/>")
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.NotEmpty(t, result["error"])
+}
+
+func TestRenderPDF_PDFAInvalidLevel(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hi\\end{document}", "options": {"pdfa": "3u"}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], "invalid pdfa")
+}
+
+func TestRenderPDF_PDFAReport(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\title{Archival}\\begin{document}Hello\\end{document}", "options": {"pdfa": "2b", "response": "report"}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report struct {
+		PDF  string `json:"pdf"`
+		PDFA struct {
+			Level       string   `json:"level"`
+			Compliant   bool     `json:"compliant"`
+			Diagnostics []string `json:"diagnostics"`
+		} `json:"pdfa"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.NotEmpty(t, report.PDF)
+	assert.Equal(t, "2b", report.PDFA.Level)
+	assert.True(t, report.PDFA.Compliant, report.PDFA.Diagnostics)
+}
+
+// renderReproducible renders a dated document reproducibly with engine at
+// epoch and returns the PDF.
+func renderReproducible(t *testing.T, engine string, epoch int64) []byte {
+	t.Helper()
+	body := fmt.Sprintf(`{"content": "\\documentclass{article}\\begin{document}Hello \\today\\end{document}", "options": {"engine": %q, "reproducible": true, "source_date_epoch": %d}}`, engine, epoch)
+	resp := postRenderPDFWithType(t, "application/json", body)
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+	return out
+}
+
+func TestRenderPDF_ReproducibleIsByteIdentical(t *testing.T) {
+	for _, engine := range []string{"pdflatex", "xelatex", "lualatex"} {
+		t.Run(engine, func(t *testing.T) {
+			first := renderReproducible(t, engine, 1700000000)
+			second := renderReproducible(t, engine, 1700000000)
+			assert.True(t, bytes.Equal(first, second), "two renders of the same input differ")
+
+			other := renderReproducible(t, engine, 1600000000)
+			assert.False(t, bytes.Equal(first, other), "source_date_epoch is not applied")
+		})
+	}
+}
+
+func TestRenderPDF_ReproducibleNeedsSourceDateEpoch(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}", "options": {"reproducible": true}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "reproducible requires source_date_epoch", result["error"])
+}
+
+func TestRenderPDF_MetadataReport(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}",
+		"metadata": {"title": "Catalogue Entry", "author": "Ada", "keywords": ["a", "b"], "custom": {"CatalogId": "X-1"}},
+		"options": {"response": "report"}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report struct {
+		Metadata struct {
+			Title    string            `json:"title"`
+			Author   string            `json:"author"`
+			Keywords []string          `json:"keywords"`
+			Custom   map[string]string `json:"custom"`
+		} `json:"metadata"`
+		Pages     int `json:"pages"`
+		PageSizes []struct {
+			Width  float64 `json:"width"`
+			Height float64 `json:"height"`
+		} `json:"page_sizes"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, "Catalogue Entry", report.Metadata.Title)
+	assert.Equal(t, "Ada", report.Metadata.Author)
+	assert.Equal(t, []string{"a", "b"}, report.Metadata.Keywords)
+	assert.Equal(t, "X-1", report.Metadata.Custom["CatalogId"])
+	assert.Equal(t, 1, report.Pages)
+	require.Len(t, report.PageSizes, 1)
+	assert.Greater(t, report.PageSizes[0].Width, 0.0)
+}
+
+func TestRenderPDF_MetadataInvalidCustomField(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}", "metadata": {"custom": {"bad key": "x"}}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Contains(t, result["error"], "invalid metadata custom field")
+}
+
+func TestRenderPDF_PostProcessWatermarkNeedsTextOrImage(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}", "post_process": {"watermark": {}}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "watermark needs either text or image", result["error"])
+}
+
+func TestRenderPDF_PostProcessEncrypt(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}",
+		"post_process": {"watermark": {"text": "DRAFT"}, "stamp": {}, "encrypt": {"owner_password": "owner", "allow": ["print"]}}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	pdf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+	assert.Contains(t, string(pdf), "/Encrypt")
+}
+
+func TestRenderPDF_PostProcessEncryptLinearized(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}",
+		"post_process": {"encrypt": {"user_password": "open sesame", "owner_password": "owner"}, "linearize": true}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	pdf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(pdf), "/Encrypt")
+	assert.Contains(t, string(pdf), "/Linearized")
+}
+
+func TestRenderPDF_EncryptPasswordLineBreak(t *testing.T) {
+	body := `{"content": "\\documentclass{article}\\begin{document}Hello\\end{document}",
+		"post_process": {"encrypt": {"owner_password": "owner\nline"}}}`
+	resp := postRenderPDFWithType(t, "application/json", body)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "encrypt passwords must not contain line breaks", result["error"])
+}
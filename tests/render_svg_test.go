@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const threePageDoc = `\documentclass{article}
+\begin{document}
+First page.
+\newpage
+Second page.
+\newpage
+Third page.
+\end{document}`
+
+// renderSVG posts a document with options to /render/svg and returns the
+// response body and content type.
+func renderSVG(t *testing.T, content string, options map[string]any) ([]byte, string) {
+	t.Helper()
+	resp := postDocument(t, "/render/svg", content, options)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+	return body, resp.Header.Get("Content-Type")
+}
+
+func TestRenderSVG_FontsAsPaths(t *testing.T) {
+	body, contentType := renderSVG(t, twoPageDoc, map[string]any{"pages": "1"})
+	assert.Equal(t, "image/svg+xml", contentType)
+
+	svg := string(body)
+	assert.Contains(t, svg, "<svg")
+	assert.Contains(t, svg, "<path")
+	assert.NotContains(t, svg, "@font-face")
+}
+
+func TestRenderSVG_EmbeddedFonts(t *testing.T) {
+	body, contentType := renderSVG(t, twoPageDoc, map[string]any{"pages": "1", "svg_fonts": "embed"})
+	assert.Equal(t, "image/svg+xml", contentType)
+
+	svg := string(body)
+	assert.Contains(t, svg, "@font-face")
+	assert.Contains(t, svg, "woff")
+}
+
+func TestRenderSVG_PDFRoute(t *testing.T) {
+	body, contentType := renderSVG(t, twoPageDoc, map[string]any{"pages": "2", "svg_route": "pdf"})
+	assert.Equal(t, "image/svg+xml", contentType)
+	assert.Contains(t, string(body), "<svg")
+}
+
+func TestRenderSVG_PageRange(t *testing.T) {
+	body, contentType := renderSVG(t, threePageDoc, map[string]any{"pages": "2-3"})
+	require.Equal(t, "application/zip", contentType)
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"page-2.svg", "page-3.svg"}, names)
+}
+
+func TestRenderSVG_PageOutOfRange(t *testing.T) {
+	resp := postDocument(t, "/render/svg", twoPageDoc, map[string]any{"pages": "3"})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "no pages selected: the document has 2", result["error"])
+}
+
+func TestRenderSVG_Links(t *testing.T) {
+	body, contentType := renderSVG(t, twoPageDoc, map[string]any{"bundle": "links"})
+	require.Contains(t, contentType, "application/json")
+
+	var links pageLinks
+	require.NoError(t, json.Unmarshal(body, &links), "body: %s", string(body))
+	require.Len(t, links.Pages, 2)
+
+	for i, link := range links.Pages {
+		assert.Equal(t, i+1, link.Page)
+		assert.Equal(t, "image/svg+xml", link.ContentType)
+		require.True(t, strings.HasPrefix(link.URL, baseURL+"/artifacts/"), "url: %s", link.URL)
+
+		page := getArtifact(t, link.URL)
+		data, err := io.ReadAll(page.Body)
+		page.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, page.StatusCode)
+		assert.Equal(t, "image/svg+xml", page.Header.Get("Content-Type"))
+		assert.Equal(t, "sandbox", page.Header.Get("Content-Security-Policy"))
+		assert.Contains(t, string(data), "<svg")
+	}
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate_Invoice(t *testing.T) {
+	resp := postJSON(t, "/templates/invoice/render", `{
+		"format": "html",
+		"data": {
+			"number": "2024_07",
+			"date": "1 July 2024",
+			"currency": "$",
+			"client": {"name": "Smith & Sons {100% #1}", "address": ["12 High St", "Leeds"]},
+			"items": [
+				{"description": "Widgets", "quantity": 2, "price": 10.5},
+				{"description": "Gadget ~ deluxe", "quantity": 1, "price": 99}
+			],
+			"total": 120,
+			"paid": true
+		}
+	}`)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+
+	html := string(body)
+	assert.Contains(t, html, "Invoice 2024_07")
+	assert.Contains(t, html, "Smith &amp; Sons {100% #1}")
+	assert.Contains(t, html, "Leeds")
+	assert.Contains(t, html, "Gadget ~ deluxe")
+	assert.Contains(t, html, "Paid, thank you.")
+	assert.NotContains(t, html, "Payment due")
+}
+
+func TestRenderTemplate_NotFound(t *testing.T) {
+	resp := postJSON(t, "/v1/templates/letter/render", `{"data": {}}`)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "template not found", result["error"])
+}
+
+func TestRenderTemplate_UnknownFormat(t *testing.T) {
+	resp := postJSON(t, "/v1/templates/invoice/render", `{"data": {}, "format": "gif"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	result := readErrorResponse(t, resp)
+	assert.Equal(t, "unknown format: gif", result["error"])
+}
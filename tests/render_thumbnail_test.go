@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertThumbnail checks that data is a PNG whose longer side is size pixels.
+func assertThumbnail(t *testing.T, data []byte, size int) {
+	t.Helper()
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, size, max(cfg.Width, cfg.Height))
+}
+
+func TestRenderThumbnail_DefaultSize(t *testing.T) {
+	resp := postDocument(t, "/render/thumbnail", twoPageDoc, nil)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assertThumbnail(t, body, 256)
+}
+
+func TestRenderThumbnail_Size(t *testing.T) {
+	resp := postDocument(t, "/render/thumbnail", twoPageDoc, map[string]any{"thumbnail_size": 64})
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(body))
+
+	assertThumbnail(t, body, 64)
+}
+
+func TestRenderThumbnail_InvalidSize(t *testing.T) {
+	for _, size := range []int{8, 2048} {
+		resp := postDocument(t, "/render/thumbnail", twoPageDoc, map[string]any{"thumbnail_size": size})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "size %d", size)
+
+		result := readErrorResponse(t, resp)
+		assert.Equal(t, "invalid thumbnail_size: must be between 16 and 1024", result["error"])
+	}
+}
+
+func TestRenderPDF_ReportThumbnail(t *testing.T) {
+	resp := postDocument(t, "/render/pdf", twoPageDoc, map[string]any{"response": "report", "thumbnail": true, "thumbnail_size": 128})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report struct {
+		PDF       string `json:"pdf"`
+		Pages     int    `json:"pages"`
+		Thumbnail *struct {
+			ContentType string `json:"content_type"`
+			Data        string `json:"data"`
+		} `json:"thumbnail"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.NotEmpty(t, report.PDF)
+	assert.Equal(t, 2, report.Pages)
+	require.NotNil(t, report.Thumbnail)
+	assert.Equal(t, "image/png", report.Thumbnail.ContentType)
+
+	data, err := base64.StdEncoding.DecodeString(report.Thumbnail.Data)
+	require.NoError(t, err)
+	assertThumbnail(t, data, 128)
+}
+
+func TestRenderPDF_ReportWithoutThumbnail(t *testing.T) {
+	resp := postDocument(t, "/render/pdf", twoPageDoc, map[string]any{"response": "report"})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Contains(t, report, "pdf")
+	assert.NotContains(t, report, "thumbnail")
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const xmlArticle = `\documentclass{article}\title{Heat flow}\author{Ada Lovelace}\begin{document}\maketitle` +
+	`\begin{abstract}We study heat.\end{abstract}` +
+	`\section{Model}\label{s:model}The flux is $q = -k\nabla T$, as in \eqref{eq:heat}.` +
+	`\begin{equation}\label{eq:heat}\partial_t T = \alpha \Delta T\end{equation}\end{document}`
+
+func TestRenderJATS_Report(t *testing.T) {
+	body, err := json.Marshal(map[string]any{"content": xmlArticle, "options": map[string]any{"response": "report"}})
+	require.NoError(t, err)
+	resp := postJSON(t, "/render/jats", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+
+	var report struct {
+		Format     string `json:"format"`
+		XML        string `json:"xml"`
+		Validation struct {
+			Schema      string   `json:"schema"`
+			Validated   bool     `json:"validated"`
+			Valid       bool     `json:"valid"`
+			Diagnostics []string `json:"diagnostics"`
+		} `json:"validation"`
+	}
+	require.NoError(t, json.Unmarshal(out, &report))
+	assert.Equal(t, "jats", report.Format)
+	assert.Contains(t, report.XML, "<article-title>Heat flow</article-title>")
+	assert.Contains(t, report.XML, "Ada Lovelace")
+	assert.Contains(t, report.XML, "<abstract>")
+	assert.Contains(t, report.XML, `<sec id="S1"`)
+	assert.Contains(t, report.XML, "<disp-formula")
+	assert.Contains(t, report.XML, "<mml:math")
+	assert.Contains(t, report.XML, "<inline-formula>")
+	assert.Equal(t, "JATS-archivearticle1-3-mathml3.dtd", report.Validation.Schema)
+	assert.True(t, report.Validation.Validated, "the JATS DTD should be installed")
+	assert.True(t, report.Validation.Valid, "diagnostics: %v", report.Validation.Diagnostics)
+}
+
+func TestRenderTEI(t *testing.T) {
+	body, err := json.Marshal(map[string]any{"content": xmlArticle})
+	require.NoError(t, err)
+	resp := postJSON(t, "/v1/render/tei", string(body))
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", string(out))
+
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/tei+xml")
+	assert.Contains(t, string(out), `<TEI xmlns="http://www.tei-c.org/ns/1.0"`)
+	assert.Contains(t, string(out), "<author>Ada Lovelace</author>")
+	assert.Contains(t, string(out), `<div type="section"`)
+	assert.Contains(t, string(out), `<formula notation="MathML"`)
+}